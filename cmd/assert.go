@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/analog-substance/nex/pkg/nmap"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of the assert command.
+const (
+	assertExitPass      = 0
+	assertExitViolation = 1
+	assertExitError     = 2
+)
+
+// assertCmd represents the assert command
+var assertCmd = &cobra.Command{
+	Use:   "assert --policy policy.yaml file/glob [file/glob...]",
+	Short: "Check scans against a policy and fail on violations",
+	Long: `Check scans against a policy and fail on violations.
+
+A policy uses the rule format of the findings command, and every rule
+has to match nothing for the policy to pass:
+
+  rules:
+    - id: public-web-only
+      title: No public ports outside 80/443
+      match:
+        public: true
+        not_ports: [80, 443]
+    - id: no-ssh-in-prod
+      title: No host in 10.50.0.0/16 exposes SSH
+      match:
+        cidrs: [10.50.0.0/16]
+        ports: [22]
+    - id: old-openssh
+      title: Nothing runs OpenSSH older than 8.0
+      severity: critical
+      match:
+        product: openssh
+        version: "<8.0"
+
+Rules without a severity are high. Exits with 0 when the policy passes,
+1 when it is violated and 2 when the policy or scans cannot be read.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		return assertInputError(cobra.MinimumNArgs(1)(cmd, args))
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		policyPath, _ := cmd.Flags().GetString("policy")
+		format, _ := cmd.Flags().GetString("format")
+		outputPath, _ := cmd.Flags().GetString("output")
+
+		if policyPath == "" {
+			checkAssertInput(fmt.Errorf("required flag \"policy\" not set"))
+		}
+
+		if format != "text" && format != "junit" && format != "sarif" {
+			checkAssertInput(fmt.Errorf("unknown format %q, expected text, junit or sarif", format))
+		}
+
+		policy, err := nmap.LoadPolicy(policyPath)
+		checkAssertInput(err)
+
+		scans, err := readAssertScans(args)
+		checkAssertInput(err)
+
+		nmapView, viewOptions, err := loadScansView(cmd, scans)
+		checkAssertInput(err)
+
+		results := nmapView.Assert(policy, viewOptions)
+
+		out := os.Stdout
+		if outputPath != "" {
+			out, err = os.Create(outputPath)
+			checkAssertInput(err)
+		}
+
+		switch format {
+		case "junit":
+			err = nmap.WriteJUnit(out, results)
+		case "sarif":
+			err = nmap.WriteSARIF(out, results)
+		default:
+			err = writeAssertText(out, results)
+		}
+		checkAssertInput(err)
+
+		exitCode := assertExitPass
+		for _, result := range results {
+			if !result.Passed() {
+				exitCode = assertExitViolation
+			}
+		}
+
+		if outputPath != "" {
+			checkAssertInput(out.Close())
+		}
+		os.Exit(exitCode)
+	},
+}
+
+// readAssertScans reads the scans matching the patterns. Unlike the other
+// commands, which skip what they can't read, it fails on a pattern matching
+// no files, a file that can't be parsed or a scan without hosts, so the
+// policy can't pass on missing input.
+func readAssertScans(patterns []string) ([]*nmap.Scan, error) {
+	var scans []*nmap.Scan
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s", pattern)
+		}
+
+		for _, path := range matches {
+			scan, err := nmap.ReadScan(path)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(scan.Run.Hosts) == 0 {
+				return nil, fmt.Errorf("no hosts in %s", path)
+			}
+			scans = append(scans, scan)
+		}
+	}
+	return scans, nil
+}
+
+// assertInputError makes err exit with assertExitError.
+func assertInputError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitCodeError{code: assertExitError, err: err}
+}
+
+// checkAssertInput exits with assertExitError if the policy, the scans or
+// the report can't be read or written.
+func checkAssertInput(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[!] %v\n", err)
+		os.Exit(assertExitError)
+	}
+}
+
+// writeAssertText writes a table of the rules and their violations followed
+// by a summary line.
+func writeAssertText(out io.Writer, results []nmap.AssertionResult) error {
+	var rows [][]string
+	failed := 0
+	for _, result := range results {
+		status := "PASS"
+		var violations []string
+		if !result.Passed() {
+			status = "FAIL"
+			failed++
+			for _, violation := range result.Violations {
+				violations = append(violations, fmt.Sprintf("%s %s", violation.Host, violation.Evidence))
+			}
+		}
+
+		rows = append(rows, []string{
+			status,
+			result.Rule.Severity,
+			fmt.Sprintf("%s\n%s", result.Rule.ID, result.Rule.Title),
+			strings.Join(violations, "\n"),
+		})
+	}
+
+	nmap.RenderTable(out, []string{"Status", "Severity", "Rule", "Violations"}, rows)
+	_, err := fmt.Fprintf(out, "%d of %d rules failed\n", failed, len(results))
+	return err
+}
+
+func init() {
+	RootCmd.AddCommand(assertCmd)
+	addViewFilterFlags(assertCmd)
+	assertCmd.Flags().String("policy", "", "YAML policy file")
+	assertCmd.Flags().String("format", "text", "Report format (text, junit, sarif)")
+	assertCmd.Flags().StringP("output", "o", "", "Write the report to this file instead of stdout")
+	assertCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return assertInputError(err)
+	})
+}
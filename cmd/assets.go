@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/analog-substance/nex/pkg/nmap"
+	"github.com/spf13/cobra"
+)
+
+// assetsCmd represents the assets command
+var assetsCmd = &cobra.Command{
+	Use:   "assets --enrich assets.csv file/glob [file/glob...]",
+	Short: "Compare scanned hosts to an asset inventory",
+	Long: `Compare scanned hosts to an asset inventory.
+
+Lists the hosts up in the scans that are not in the asset inventory, which
+may be shadow IT, and the assets never seen up in the scans. Assets match
+hosts by IP, CIDR or hostname. Use --enrich-map when the inventory does not
+use common column names:
+
+  nex assets --enrich cmdb.csv --enrich-map "ip=Primary IP,hostname=DNS Name" scans/*.xml`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		enrichPath, _ := cmd.Flags().GetString("enrich")
+		unknownOnly, _ := cmd.Flags().GetBool("unknown")
+		unseenOnly, _ := cmd.Flags().GetBool("unseen")
+
+		if enrichPath == "" {
+			check(fmt.Errorf("an asset inventory is needed, use --enrich"))
+		}
+
+		nmapView, viewOptions := newFilteredView(cmd, args)
+		report := nmapView.CompareAssets(viewOptions)
+
+		if unknownOnly {
+			report.Unseen = []*nmap.Asset{}
+		}
+
+		if unseenOnly {
+			report.Unknown = []nmap.UnknownHost{}
+		}
+
+		headers := []string{"Status", "Host", "Hostnames", "Details"}
+		var rows [][]string
+		for _, unknown := range report.Unknown {
+			rows = append(rows, []string{
+				"not in inventory",
+				unknown.Host,
+				strings.Join(unknown.Hostnames, "\n"),
+				strings.Join(unknown.OpenPorts, "\n"),
+			})
+		}
+
+		for _, asset := range report.Unseen {
+			var details []string
+			for _, key := range slices.Sorted(maps.Keys(asset.Attributes)) {
+				details = append(details, fmt.Sprintf("%s: %s", key, asset.Attributes[key]))
+			}
+
+			rows = append(rows, []string{
+				"never seen up",
+				strings.Join(asset.Addresses, "\n"),
+				strings.Join(asset.Hostnames, "\n"),
+				strings.Join(details, "\n"),
+			})
+		}
+		printOutput(cmd, report, headers, rows)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(assetsCmd)
+	addViewFilterFlags(assetsCmd)
+	addOutputFlags(assetsCmd)
+	assetsCmd.Flags().Bool("unknown", false, "Only show hosts not in the asset inventory")
+	assetsCmd.Flags().Bool("unseen", false, "Only show assets never seen up")
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/analog-substance/nex/pkg/nmap"
+	"github.com/spf13/cobra"
+)
+
+// baselineCmd represents the baseline command
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Compare scans to a baseline of approved open ports",
+	Long: `Compare scans to a baseline of approved open ports.
+
+A baseline is a YAML file of expected open ports:
+
+  entries:
+    - target: 10.0.0.1
+      port: 22
+      service: ssh
+      product: OpenSSH
+    - target: 10.50.0.0/16
+      port: 443
+      expires: 2026-12-31
+      note: Load balancers, approved in NET-1234
+
+Targets are IPs, CIDRs, IP ranges or hostnames. The protocol defaults to
+tcp, service and product are only compared when set, products without their
+version, and entries are valid through their expiry date.`,
+}
+
+// baselineCreateCmd represents the baseline create command
+var baselineCreateCmd = &cobra.Command{
+	Use:   "create --output baseline.yaml file/glob [file/glob...]",
+	Short: "Snapshot the open ports of the scans into a baseline",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		note, _ := cmd.Flags().GetString("note")
+		expires, _ := cmd.Flags().GetString("expires")
+
+		nmapView, viewOptions := newFilteredView(cmd, args)
+
+		baseline, err := nmapView.CreateBaseline(viewOptions, note, expires, time.Now())
+		check(err)
+
+		err = baseline.Write(output)
+		check(err)
+
+		fmt.Printf("[+] Wrote %d expected ports to %s\n", len(baseline.Entries), output)
+	},
+}
+
+// baselineCheckCmd represents the baseline check command
+var baselineCheckCmd = &cobra.Command{
+	Use:   "check --baseline baseline.yaml file/glob [file/glob...]",
+	Short: "Report differences between the scans and a baseline",
+	Long: `Report differences between the scans and a baseline.
+
+Open ports without an entry are unexpected, or expired when their entries
+have expired. Entries without an open port are missing when the scans
+covered their port on a matching host, and ports running a different
+service or product than their entry are changed. Products are compared
+without their version. Exits with 1 when there are differences.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		baselinePath, _ := cmd.Flags().GetString("baseline")
+
+		baseline, err := nmap.ReadBaseline(baselinePath)
+		check(err)
+
+		scans, err := nmap.ReadScans(getFiles(args))
+		check(err)
+
+		nmapView, viewOptions := newScansView(cmd, scans)
+		changes := nmapView.CheckBaseline(baseline, nmap.HostCoverage(scans), viewOptions, time.Now())
+
+		headers := []string{"Change", "Host", "Port", "Expected", "Actual", "Note"}
+		var rows [][]string
+		for _, change := range changes {
+			host := change.Host
+			if len(change.Hostnames) > 0 {
+				host = fmt.Sprintf("%s\n%s", host, strings.Join(change.Hostnames, "\n"))
+			}
+
+			note := change.Note
+			if change.Expires != "" {
+				note = strings.TrimSpace(fmt.Sprintf("%s\nexpires %s", note, change.Expires))
+			}
+
+			rows = append(rows, []string{
+				change.Kind,
+				host,
+				fmt.Sprintf("%d/%s", change.Port, change.Protocol),
+				change.Expected,
+				change.Actual,
+				note,
+			})
+		}
+		printOutput(cmd, changes, headers, rows)
+
+		if len(changes) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(baselineCmd)
+	baselineCmd.AddCommand(baselineCreateCmd)
+	baselineCmd.AddCommand(baselineCheckCmd)
+
+	addViewFilterFlags(baselineCreateCmd)
+	baselineCreateCmd.Flags().StringP("output", "o", "baseline.yaml", "File to write the baseline to")
+	baselineCreateCmd.Flags().String("note", "", "Justification note to set on every entry")
+	baselineCreateCmd.Flags().String("expires", "", "Expiry date (YYYY-MM-DD) to set on every entry")
+
+	addViewFilterFlags(baselineCheckCmd)
+	addOutputFlags(baselineCheckCmd)
+	baselineCheckCmd.Flags().StringP("baseline", "b", "baseline.yaml", "Baseline file to check against")
+}
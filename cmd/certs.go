@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/analog-substance/nex/pkg/nmap"
+	"github.com/spf13/cobra"
+)
+
+// certsCmd represents the certs command
+var certsCmd = &cobra.Command{
+	Use:   "certs file/glob [file/glob...]",
+	Short: "View the TLS certificates found by ssl-cert",
+	Long: `View the TLS certificates found by the ssl-cert script.
+
+Endpoints sharing a certificate are grouped together. Certificates are
+flagged as expired, expiring, self-signed, weak-key (RSA/DSA under 2048 bits,
+EC under 224 bits) or weak-signature (MD5 or SHA-1).`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		days, _ := cmd.Flags().GetInt("expiring-days")
+		flagged, _ := cmd.Flags().GetBool("flagged")
+
+		nmapView, viewOptions := newFilteredView(cmd, args)
+		certs := nmapView.GetCertificates(viewOptions, nmap.CertOptions{
+			ExpiringWithin: time.Duration(days) * 24 * time.Hour,
+		})
+
+		if flagged {
+			var flaggedCerts []nmap.Certificate
+			for _, cert := range certs {
+				if len(cert.Flags) > 0 {
+					flaggedCerts = append(flaggedCerts, cert)
+				}
+			}
+			certs = flaggedCerts
+		}
+
+		headers := []string{"Subject", "SANs", "Issuer", "Key", "Signature", "Not Before", "Not After", "SHA-256", "Flags", "Endpoints"}
+		var rows [][]string
+		for _, cert := range certs {
+			rows = append(rows, []string{
+				cert.CommonName(),
+				strings.Join(cert.DNSNames(), "\n"),
+				cert.Issuer["commonName"],
+				fmt.Sprintf("%s %d", cert.KeyType, cert.KeyBits),
+				cert.SignatureAlgorithm,
+				formatDate(cert.NotBefore),
+				formatDate(cert.NotAfter),
+				cert.SHA256,
+				strings.Join(cert.Flags, "\n"),
+				strings.Join(cert.Endpoints, "\n"),
+			})
+		}
+		printOutput(cmd, certs, headers, rows)
+	},
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.DateOnly)
+}
+
+func init() {
+	RootCmd.AddCommand(certsCmd)
+	addViewFilterFlags(certsCmd)
+	addOutputFlags(certsCmd)
+	certsCmd.Flags().Int("expiring-days", 30, "Flag certificates expiring within this many days")
+	certsCmd.Flags().Bool("flagged", false, "Only show flagged certificates")
+}
@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/analog-substance/nex/pkg/nmap"
+	"github.com/spf13/cobra"
+)
+
+// getFiles expands the file globs passed as arguments.
+func getFiles(args []string) []string {
+	var files []string
+	for _, pattern := range args {
+		matches, err := filepath.Glob(pattern)
+		check(err)
+
+		files = append(files, matches...)
+	}
+
+	if len(files) == 0 {
+		check(fmt.Errorf("no files found"))
+	}
+	return files
+}
+
+// addViewFilterFlags adds the flags used to filter the hosts and ports of a
+// view.
+func addViewFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("open", false, "Show only hosts with open ports")
+	cmd.Flags().Bool("up", false, "Show only hosts that are up")
+	cmd.Flags().Bool("private", false, "Only show hosts with private IPs")
+	cmd.Flags().Bool("public", false, "Only show hosts with public IPs")
+	cmd.Flags().Bool("no-tcpwrapped", false, "Do not show TCPWrapped ports")
+	cmd.Flags().IntSlice("exclude-ports", []int{}, "Exclude these ports from the output")
+	cmd.Flags().IntSlice("include-ports", []int{}, "Include these ports from the output")
+	cmd.Flags().StringSlice("exclude", []string{}, "exclude")
+	cmd.Flags().StringSlice("include", []string{}, "include")
+	cmd.Flags().String("enrich", "", "Asset inventory CSV (like a CMDB export) to add owner, environment and other attributes to hosts")
+	cmd.Flags().StringSlice("enrich-map", []string{}, "Map asset inventory columns, like ip=IP Address,owner=Owner Name (keys ip, hostname and cidr match hosts)")
+	cmd.Flags().StringSlice("geoip", []string{}, "MaxMind DB files (like GeoLite2 City and ASN) to add the country, city, asn and as_org of public IPs to hosts, defaults to $NEX_GEOIP")
+	addDNSRecordsFlag(cmd)
+	cmd.Flags().StringArray("where", []string{}, "Only show hosts whose attributes match, like \"environment == prod\" or \"owner =~ ^net\" (==, !=, =~, !~)")
+}
+
+// addDNSRecordsFlag adds the flag to match hosts and build URLs by the names
+// found in subdomain enumeration outputs.
+func addDNSRecordsFlag(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("dns-records", []string{}, "amass, subfinder, dnsx or massdns output files whose A, AAAA and CNAME records add names to match and build URLs for")
+}
+
+// setDNSRecords loads the files of the dns-records flag into the view.
+func setDNSRecords(cmd *cobra.Command, v *nmap.View) error {
+	paths, _ := cmd.Flags().GetStringSlice("dns-records")
+	if len(paths) == 0 {
+		return nil
+	}
+
+	records, err := nmap.LoadDNSRecords(paths...)
+	if err != nil {
+		return err
+	}
+
+	v.SetDNSRecords(records)
+	return nil
+}
+
+// newFilteredView merges the files matching args and returns a view set up
+// with the filters added by addViewFilterFlags.
+func newFilteredView(cmd *cobra.Command, args []string) (*nmap.View, nmap.ViewOptions) {
+	scans, err := nmap.ReadScans(getFiles(args))
+	check(err)
+
+	return newScansView(cmd, scans)
+}
+
+// newScansView merges the scans and returns a view set up with the filters
+// added by addViewFilterFlags, for commands that also use the individual
+// scans.
+func newScansView(cmd *cobra.Command, scans []*nmap.Scan) (*nmap.View, nmap.ViewOptions) {
+	nmapView, viewOptions, err := loadScansView(cmd, scans)
+	check(err)
+
+	return nmapView, viewOptions
+}
+
+// loadScansView is newScansView returning errors instead of exiting, for
+// commands with their own exit codes.
+func loadScansView(cmd *cobra.Command, scans []*nmap.Scan) (*nmap.View, nmap.ViewOptions, error) {
+	excludeThings, _ := cmd.Flags().GetStringSlice("exclude")
+	includeThings, _ := cmd.Flags().GetStringSlice("include")
+	includePublic, _ := cmd.Flags().GetBool("public")
+	includePrivate, _ := cmd.Flags().GetBool("private")
+	openOnly, _ := cmd.Flags().GetBool("open")
+	upOnly, _ := cmd.Flags().GetBool("up")
+	noTCPWrapped, _ := cmd.Flags().GetBool("no-tcpwrapped")
+	excludePorts, _ := cmd.Flags().GetIntSlice("exclude-ports")
+	includePorts, _ := cmd.Flags().GetIntSlice("include-ports")
+	enrichPath, _ := cmd.Flags().GetString("enrich")
+	enrichMap, _ := cmd.Flags().GetStringSlice("enrich-map")
+	geoIPPaths, _ := cmd.Flags().GetStringSlice("geoip")
+	where, _ := cmd.Flags().GetStringArray("where")
+
+	run, err := nmap.MergeScans(scans)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	nmapView := nmap.NewNmapView(run)
+
+	nmapView.SetExcludePorts(excludePorts)
+	nmapView.SetIncludePorts(includePorts)
+
+	if len(excludeThings) > 0 || len(includeThings) > 0 {
+		nmapView.SetFilter(nmap.HostListFilter(includeThings, excludeThings))
+	}
+
+	if enrichPath != "" {
+		mapping, err := nmap.ParseAssetMapping(enrichMap)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		inventory, err := nmap.LoadAssets(enrichPath, mapping)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		nmapView.SetAssets(inventory)
+	}
+
+	if len(geoIPPaths) == 0 && os.Getenv("NEX_GEOIP") != "" {
+		geoIPPaths = strings.Split(os.Getenv("NEX_GEOIP"), ",")
+	}
+
+	if len(geoIPPaths) > 0 {
+		geoIP, err := nmap.OpenGeoIP(geoIPPaths...)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		nmapView.SetGeoIP(geoIP)
+	}
+
+	err = setDNSRecords(cmd, nmapView)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var conditions []nmap.WhereCondition
+	for _, condition := range where {
+		parsed, err := nmap.ParseWhereCondition(condition)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		conditions = append(conditions, parsed)
+	}
+	err = nmapView.SetWhere(conditions)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	viewOptions := nmap.ViewOptions(0)
+	if includePublic {
+		viewOptions = viewOptions | nmap.ViewPublic
+	}
+
+	if includePrivate {
+		viewOptions = viewOptions | nmap.ViewPrivate
+	}
+
+	if upOnly {
+		viewOptions = viewOptions | nmap.ViewAliveHosts
+	}
+
+	if openOnly {
+		viewOptions = viewOptions | nmap.ViewOpenPorts
+	}
+
+	if noTCPWrapped {
+		viewOptions = viewOptions | nmap.IgnoreTCPWrapped
+	}
+
+	return nmapView, viewOptions, nil
+}
+
+// addOutputFlags adds the flags used to choose between table, JSON, CSV and
+// Markdown output.
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("json", false, "Print JSON")
+	cmd.Flags().Bool("csv", false, "Print CSV")
+	cmd.Flags().Bool("markdown", false, "Print a Markdown table")
+}
+
+// printOutput prints value as JSON or the rows as CSV, Markdown or a table,
+// depending on the flags added by addOutputFlags.
+func printOutput(cmd *cobra.Command, value any, headers []string, rows [][]string) {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	csvOutput, _ := cmd.Flags().GetBool("csv")
+	markdownOutput, _ := cmd.Flags().GetBool("markdown")
+
+	if jsonOutput {
+		output, err := json.MarshalIndent(value, "", "  ")
+		check(err)
+
+		fmt.Println(string(output))
+		return
+	}
+
+	if csvOutput {
+		check(nmap.WriteCSV(os.Stdout, headers, rows))
+		return
+	}
+
+	if markdownOutput {
+		check(nmap.WriteMarkdown(os.Stdout, headers, rows))
+		return
+	}
+
+	nmap.RenderTable(os.Stdout, headers, rows)
+}
+
+// addCVEFlags adds the flag used to load CVE indexes for matching service
+// versions to CVEs.
+func addCVEFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("cves", []string{}, "NVD JSON feeds or indexes from cve-index build to match service versions against")
+}
+
+// setCVEIndex loads the CVE indexes passed with the flag added by
+// addCVEFlags into the view.
+func setCVEIndex(cmd *cobra.Command, nmapView *nmap.View) {
+	paths, _ := cmd.Flags().GetStringSlice("cves")
+	if len(paths) == 0 {
+		return
+	}
+
+	index, err := nmap.LoadCVEIndex(getFiles(paths)...)
+	check(err)
+
+	nmapView.SetCVEIndex(index)
+}
+
+// addTriageFlags adds the flags used to load annotations and filter ports by
+// their triage state.
+func addTriageFlags(cmd *cobra.Command) {
+	addTriageFileFlag(cmd)
+	cmd.Flags().StringSlice("triage", []string{}, fmt.Sprintf("Only show open ports in these triage states: %s", strings.Join(nmap.TriageStates(), ", ")))
+}
+
+// addTriageFileFlag adds the flag used to choose the annotations file.
+func addTriageFileFlag(cmd *cobra.Command) {
+	cmd.Flags().String("triage-file", "nex-triage.json", "JSON file the notes and triage states are kept in")
+}
+
+// loadAnnotations loads the annotations file passed with the flag added by
+// addTriageFileFlag.
+func loadAnnotations(cmd *cobra.Command) *nmap.Annotations {
+	path, _ := cmd.Flags().GetString("triage-file")
+
+	annotations, err := nmap.LoadAnnotations(path)
+	check(err)
+	return annotations
+}
+
+// setAnnotations loads the annotations into the view and sets the triage
+// filter, using the flags added by addTriageFlags.
+func setAnnotations(cmd *cobra.Command, nmapView *nmap.View) {
+	states, _ := cmd.Flags().GetStringSlice("triage")
+
+	nmapView.SetAnnotations(loadAnnotations(cmd))
+	check(nmapView.SetTriageFilter(states))
+}
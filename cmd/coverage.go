@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/analog-substance/nex/pkg/nmap"
+	"github.com/spf13/cobra"
+)
+
+// coverageCmd represents the coverage command
+var coverageCmd = &cobra.Command{
+	Use:   "coverage --scope scope.txt file/glob [file/glob...]",
+	Short: "Report how well the targets in a scope file were scanned",
+	Long: `Report how well the targets in a scope file were scanned.
+
+The scope file has one IP, CIDR, IP range (10.0.0.1-50) or hostname per line.
+Every in scope target is reported as up, down, skipped, unknown or not
+scanned, along with the TCP and UDP port ranges it was scanned on. Targets
+are unknown when a scan read its targets from an -iL list that no longer
+exists. Hosts found up in the scans that are not in scope are reported
+separately.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		scopePath, _ := cmd.Flags().GetString("scope")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		scope, err := nmap.ReadScope(scopePath)
+		check(err)
+
+		scans, err := nmap.ReadScans(getFiles(args))
+		check(err)
+
+		report, err := nmap.NewCoverageReport(scope, scans)
+		check(err)
+
+		if jsonOutput {
+			output, err := json.MarshalIndent(report, "", "  ")
+			check(err)
+
+			fmt.Println(string(output))
+			return
+		}
+
+		headers := []string{"Target", "Status", "Reason", "TCP", "UDP", "Scans"}
+		nmap.RenderTable(os.Stdout, headers, coverageRows(report.InScope))
+
+		if len(report.OutOfScope) > 0 {
+			fmt.Println("Out of scope hosts found in the scans:")
+			nmap.RenderTable(os.Stdout, headers, coverageRows(report.OutOfScope))
+		}
+
+		var keys []string
+		for key := range report.Summary {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fmt.Printf("%s: %d\n", key, report.Summary[key])
+		}
+	},
+}
+
+func coverageRows(entries []nmap.ScopeCoverage) [][]string {
+	var rows [][]string
+	for _, entry := range entries {
+		rows = append(rows, []string{
+			entry.Target,
+			entry.Status,
+			entry.Reason,
+			entry.TCP,
+			entry.UDP,
+			fmt.Sprint(len(entry.Scans)),
+		})
+	}
+	return rows
+}
+
+func init() {
+	RootCmd.AddCommand(coverageCmd)
+	coverageCmd.Flags().StringP("scope", "s", "", "File with the in scope IPs, CIDRs, IP ranges and hostnames")
+	coverageCmd.MarkFlagRequired("scope")
+	coverageCmd.Flags().Bool("json", false, "Print JSON")
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/analog-substance/nex/pkg/nmap"
+	"github.com/spf13/cobra"
+)
+
+// cveIndexCmd represents the cve-index command
+var cveIndexCmd = &cobra.Command{
+	Use:   "cve-index",
+	Short: "Manage offline CVE indexes built from NVD JSON feeds",
+}
+
+// cveIndexBuildCmd represents the cve-index build command
+var cveIndexBuildCmd = &cobra.Command{
+	Use:   "build --output index.json.gz feed [feed...]",
+	Short: "Build a compact CVE index from NVD JSON feeds",
+	Long: `Build a compact CVE index from NVD JSON feeds.
+
+Both the NVD 1.1 JSON feeds and NVD 2.0 API responses are supported, gzipped
+or not. The index only keeps the vulnerable CPEs, version ranges and CVSS
+scores, so it loads a lot faster than the feeds with --cves.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+
+		index, err := nmap.LoadCVEIndex(getFiles(args)...)
+		check(err)
+
+		err = index.Write(output)
+		check(err)
+
+		fmt.Printf("[+] Wrote %d CVEs for %d products to %s\n", index.CVECount(), len(index.Products), output)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(cveIndexCmd)
+	cveIndexCmd.AddCommand(cveIndexBuildCmd)
+	cveIndexBuildCmd.Flags().StringP("output", "o", "cve-index.json.gz", "File to write the index to, gzipped if it ends in .gz")
+}
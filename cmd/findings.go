@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/analog-substance/nex/pkg/nmap"
+	"github.com/spf13/cobra"
+)
+
+// findingsCmd represents the findings command
+var findingsCmd = &cobra.Command{
+	Use:   "findings file/glob [file/glob...]",
+	Short: "Flag risky exposures using the built in and custom rules",
+	Long: `Flag risky exposures using the built in and custom rules.
+
+Rules are YAML files with a list of rules:
+
+  rules:
+    - id: jenkins-public
+      title: Jenkins exposed to the internet
+      severity: high
+      match:
+        public: true
+        product: jenkins
+
+Match conditions: ports, not_ports, services, protocol, product, version
+(like ">=2.0,<2.5"), public, cidrs, script and script_output (a regex).
+A rule with the id of a built in rule replaces it, and "disabled: true"
+turns it off. Use --list-rules to see the built in rules.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		rulePaths, _ := cmd.Flags().GetStringSlice("rules")
+		noDefaultRules, _ := cmd.Flags().GetBool("no-default-rules")
+		minSeverity, _ := cmd.Flags().GetString("min-severity")
+		listRules, _ := cmd.Flags().GetBool("list-rules")
+
+		if nmap.SeverityRank(minSeverity) == -1 {
+			check(fmt.Errorf("unknown severity %q", minSeverity))
+		}
+
+		rules, err := nmap.LoadRules(!noDefaultRules, rulePaths...)
+		check(err)
+
+		if listRules {
+			var rows [][]string
+			for _, rule := range rules {
+				rows = append(rows, []string{rule.ID, rule.Severity, rule.Title})
+			}
+			printOutput(cmd, rules, []string{"ID", "Severity", "Title"}, rows)
+			return
+		}
+
+		if len(args) == 0 {
+			check(fmt.Errorf("no nmap files given"))
+		}
+
+		nmapView, viewOptions := newFilteredView(cmd, args)
+
+		var findings []nmap.Finding
+		for _, finding := range nmapView.GetFindings(rules, viewOptions) {
+			if nmap.SeverityRank(finding.Severity) >= nmap.SeverityRank(minSeverity) {
+				findings = append(findings, finding)
+			}
+		}
+
+		headers := []string{"Severity", "Rule", "Host", "Port", "Evidence"}
+		var rows [][]string
+		for _, finding := range findings {
+			host := finding.Host
+			if len(finding.Hostnames) > 0 {
+				host = fmt.Sprintf("%s\n%s", host, strings.Join(finding.Hostnames, "\n"))
+			}
+
+			port := ""
+			if finding.Port != 0 {
+				port = fmt.Sprintf("%d/%s", finding.Port, finding.Protocol)
+			}
+
+			rows = append(rows, []string{
+				finding.Severity,
+				fmt.Sprintf("%s\n%s", finding.RuleID, finding.Title),
+				host,
+				port,
+				finding.Evidence,
+			})
+		}
+		printOutput(cmd, findings, headers, rows)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(findingsCmd)
+	addViewFilterFlags(findingsCmd)
+	addOutputFlags(findingsCmd)
+	findingsCmd.Flags().StringSlice("rules", []string{}, "YAML rule files to add to the built in rules")
+	findingsCmd.Flags().Bool("no-default-rules", false, "Do not use the built in rules")
+	findingsCmd.Flags().String("min-severity", nmap.SeverityInfo, "Only show findings at least this severe (info, low, medium, high, critical)")
+	findingsCmd.Flags().Bool("list-rules", false, "List the rules instead of running them")
+}
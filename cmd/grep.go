@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/analog-substance/nex/pkg/nmap"
+	"github.com/spf13/cobra"
+)
+
+// grepCmd represents the grep command
+var grepCmd = &cobra.Command{
+	Use:   "grep regex file/glob [file/glob...]",
+	Short: "Search NSE script output",
+	Long: `Search NSE script output.
+
+The regex is matched against the ID, output and structured elements of every
+host and port script. Structured elements are searched as "table.key: value"
+lines. Matching lines are printed with ":" after the line number and context
+lines with "-".`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		scripts, _ := cmd.Flags().GetStringSlice("script")
+		context, _ := cmd.Flags().GetInt("context")
+		ignoreCase, _ := cmd.Flags().GetBool("ignore-case")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		pattern := args[0]
+		if ignoreCase {
+			pattern = "(?i)" + pattern
+		}
+
+		re, err := regexp.Compile(pattern)
+		check(err)
+
+		nmapView, viewOptions := newFilteredView(cmd, args[1:])
+		matches := nmapView.GrepScripts(re, viewOptions, nmap.GrepOptions{
+			Scripts: scripts,
+			Context: context,
+		})
+
+		if jsonOutput {
+			output, err := json.MarshalIndent(matches, "", "  ")
+			check(err)
+
+			fmt.Println(string(output))
+			return
+		}
+
+		for _, match := range matches {
+			host := strings.Join(match.IPs, ", ")
+			if len(match.Hostnames) > 0 {
+				host = fmt.Sprintf("%s (%s)", host, strings.Join(match.Hostnames, ", "))
+			}
+
+			fmt.Printf("%s %s %s\n", host, match.Port, match.ScriptID)
+			for i, line := range match.Lines {
+				if i > 0 && line.Number != match.Lines[i-1].Number+1 {
+					fmt.Println("  --")
+				}
+
+				sep := "-"
+				if line.Match {
+					sep = ":"
+				}
+				fmt.Printf("  %d%s%s\n", line.Number, sep, line.Text)
+			}
+			fmt.Println()
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(grepCmd)
+	addViewFilterFlags(grepCmd)
+	grepCmd.Flags().StringSlice("script", []string{}, "Only search these script IDs")
+	grepCmd.Flags().IntP("context", "C", 2, "Lines of context to show around each match")
+	grepCmd.Flags().BoolP("ignore-case", "i", false, "Match case insensitively")
+	grepCmd.Flags().Bool("json", false, "Print JSON")
+}
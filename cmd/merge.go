@@ -18,6 +18,14 @@ var mergeCmd = &cobra.Command{
 		openOnly, _ := cmd.Flags().GetBool("open")
 		upOnly, _ := cmd.Flags().GetBool("up")
 		output, _ := cmd.Flags().GetString("output")
+		includePublic, _ := cmd.Flags().GetBool("public")
+		includePrivate, _ := cmd.Flags().GetBool("private")
+		noTCPWrapped, _ := cmd.Flags().GetBool("no-tcpwrapped")
+		excludePorts, _ := cmd.Flags().GetIntSlice("exclude-ports")
+		includePorts, _ := cmd.Flags().GetIntSlice("include-ports")
+		excludeThings, _ := cmd.Flags().GetStringSlice("exclude")
+		includeThings, _ := cmd.Flags().GetStringSlice("include")
+		noTimestamps, _ := cmd.Flags().GetBool("no-timestamps")
 
 		var files []string
 		for _, pattern := range args {
@@ -38,6 +46,28 @@ var mergeCmd = &cobra.Command{
 		if openOnly {
 			opts = append(opts, nmap.WithOpenOnly())
 		}
+		if includePublic {
+			opts = append(opts, nmap.WithPublicOnly())
+		}
+		if includePrivate {
+			opts = append(opts, nmap.WithPrivateOnly())
+		}
+		if noTCPWrapped {
+			opts = append(opts, nmap.WithoutTCPWrapped())
+		}
+		if len(excludePorts) > 0 {
+			opts = append(opts, nmap.WithExcludePorts(excludePorts))
+		}
+		if len(includePorts) > 0 {
+			opts = append(opts, nmap.WithIncludePorts(includePorts))
+		}
+		if len(excludeThings) > 0 || len(includeThings) > 0 {
+			opts = append(opts, nmap.WithHosts(includeThings, excludeThings))
+		}
+
+		if noTimestamps {
+			opts = append(opts, nmap.WithoutTimestamps())
+		}
 
 		run, err := nmap.XMLMerge(files, opts...)
 		check(err)
@@ -49,7 +79,7 @@ var mergeCmd = &cobra.Command{
 
 func check(err error) {
 	if err != nil {
-		fmt.Printf("[!] %v", err)
+		fmt.Fprintf(os.Stderr, "[!] %v\n", err)
 		os.Exit(1)
 	}
 }
@@ -60,4 +90,12 @@ func init() {
 	mergeCmd.Flags().StringP("output", "o", "nmap-merge.xml", "Output of resulting merged file.")
 	mergeCmd.Flags().Bool("open", false, "Merge only hosts with open ports")
 	mergeCmd.Flags().Bool("up", false, "Merge only hosts that are up")
+	mergeCmd.Flags().Bool("private", false, "Merge only hosts with private IPs")
+	mergeCmd.Flags().Bool("public", false, "Merge only hosts with public IPs")
+	mergeCmd.Flags().Bool("no-tcpwrapped", false, "Do not merge TCPWrapped ports")
+	mergeCmd.Flags().IntSlice("exclude-ports", []int{}, "Exclude these ports from the merged file")
+	mergeCmd.Flags().IntSlice("include-ports", []int{}, "Merge only these ports")
+	mergeCmd.Flags().StringSlice("exclude", []string{}, "Exclude these hostnames or IPs")
+	mergeCmd.Flags().StringSlice("include", []string{}, "Merge only these hostnames or IPs")
+	mergeCmd.Flags().Bool("no-timestamps", false, "Leave out the nex-last-confirmed port timestamps, for tools that don't expect them (merging the output again is then not time-aware)")
 }
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// noteCmd represents the note command
+var noteCmd = &cobra.Command{
+	Use:   "note target [text...]",
+	Short: "Add notes and tags to hosts and ports",
+	Long: `Add notes and tags to hosts and ports.
+
+Targets are an IP or hostname, optionally with a port and protocol, like
+10.0.0.1, web.example.com:443 or 10.0.0.1:53/udp. Notes are shown in the JSON
+output of view, and tags with "nex view --columns tags".`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		tags, _ := cmd.Flags().GetStringSlice("tag")
+		untags, _ := cmd.Flags().GetStringSlice("untag")
+
+		annotations := loadAnnotations(cmd)
+
+		text := strings.Join(args[1:], " ")
+		check(annotations.AddNote(args[0], text, tags, untags, time.Now()))
+		check(annotations.Save())
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(noteCmd)
+	addTriageFileFlag(noteCmd)
+	noteCmd.Flags().StringSlice("tag", []string{}, "Tags to add")
+	noteCmd.Flags().StringSlice("untag", []string{}, "Tags to remove")
+}
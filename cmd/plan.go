@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/analog-substance/nex/pkg/nmap"
+	"github.com/spf13/cobra"
+)
+
+// planCmd represents the plan command
+var planCmd = &cobra.Command{
+	Use:   "plan file/glob [file/glob...]",
+	Short: "Plan follow-up nmap scans from previous scan results",
+	Long: `Plan follow-up nmap scans from previous scan results.
+
+Looks at what the scans covered and found, then prints nmap commands to:
+  - run version detection and default scripts on open ports that lack it
+  - rescan hosts that had every port filtered without host discovery
+  - scan the full TCP port range where only part of it was scanned
+  - scan top UDP ports on hosts without any UDP coverage
+
+Hosts needing the same scan are grouped into a single command.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		udpTopPorts, _ := cmd.Flags().GetInt("udp-top-ports")
+		outputPrefix, _ := cmd.Flags().GetString("output-prefix")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		scans, err := nmap.ReadScans(getFiles(args))
+		check(err)
+
+		nmapView, viewOptions := newScansView(cmd, scans)
+		planned := nmapView.Plan(nmap.HostCoverage(scans), viewOptions, nmap.PlanOptions{
+			UDPTopPorts:  udpTopPorts,
+			OutputPrefix: outputPrefix,
+		})
+
+		if jsonOutput {
+			output, err := json.MarshalIndent(planned, "", "  ")
+			check(err)
+
+			fmt.Println(string(output))
+			return
+		}
+
+		for _, scan := range planned {
+			fmt.Printf("# %s (%d hosts)\n", scan.Reason, len(scan.Hosts))
+			fmt.Println(scan.Command())
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(planCmd)
+	addViewFilterFlags(planCmd)
+	planCmd.Flags().Int("udp-top-ports", 100, "Number of top UDP ports to scan on hosts without UDP coverage")
+	planCmd.Flags().String("output-prefix", "nex-plan", "Prefix for the -oA output of each planned scan. Empty to leave out -oA")
+	planCmd.Flags().Bool("json", false, "Print JSON")
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// portsCmd represents the ports command
+var portsCmd = &cobra.Command{
+	Use:   "ports file/glob [file/glob...]",
+	Short: "View open ports and the hosts behind them",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		csvOutput, _ := cmd.Flags().GetBool("csv")
+
+		nmapView, viewOptions := newFilteredView(cmd, args)
+		ports := nmapView.GetPortSummaries(viewOptions)
+
+		// a single line per row is easier to work with in a spreadsheet
+		sep := "\n"
+		if csvOutput {
+			sep = " "
+		}
+
+		headers := []string{"Port", "Protocol", "Services", "Hosts", "Addresses"}
+		var rows [][]string
+		for _, port := range ports {
+			rows = append(rows, []string{
+				fmt.Sprint(port.Port),
+				port.Protocol,
+				strings.Join(port.Services, ", "),
+				fmt.Sprint(port.Hosts),
+				strings.Join(port.Addresses, sep),
+			})
+		}
+		printOutput(cmd, ports, headers, rows)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(portsCmd)
+	addViewFilterFlags(portsCmd)
+	addOutputFlags(portsCmd)
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/analog-substance/nex/pkg/nmap"
+	"github.com/spf13/cobra"
+)
+
+// servicesCmd represents the services command
+var servicesCmd = &cobra.Command{
+	Use:   "services file/glob [file/glob...]",
+	Short: "View open ports grouped by service",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		service, _ := cmd.Flags().GetString("service")
+		product, _ := cmd.Flags().GetString("product")
+		version, _ := cmd.Flags().GetString("version")
+		groupBy, _ := cmd.Flags().GetString("group-by")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		versions, err := nmap.ParseVersionConstraints(version)
+		check(err)
+
+		if groupBy != nmap.GroupByVersion && groupBy != nmap.GroupByProduct && groupBy != nmap.GroupByCPE {
+			check(fmt.Errorf("unknown group %q, expected one of: %s, %s, %s", groupBy, nmap.GroupByVersion, nmap.GroupByProduct, nmap.GroupByCPE))
+		}
+
+		nmapView, viewOptions := newFilteredView(cmd, args)
+		services := nmapView.GetServices(viewOptions, nmap.ServiceFilter{
+			Service:  service,
+			Product:  product,
+			Versions: versions,
+			GroupBy:  groupBy,
+		})
+
+		if jsonOutput {
+			output, err := json.MarshalIndent(services, "", "  ")
+			check(err)
+
+			fmt.Println(string(output))
+			return
+		}
+
+		headers := []string{"Service", "Product", "Version", "Hosts", "Endpoints"}
+		if groupBy == nmap.GroupByCPE {
+			headers[2] = "CPE"
+		}
+
+		var rows [][]string
+		for _, svc := range services {
+			versionOrCPE := svc.Version
+			if groupBy == nmap.GroupByCPE {
+				versionOrCPE = svc.CPE
+			}
+
+			rows = append(rows, []string{
+				svc.Name,
+				svc.Product,
+				versionOrCPE,
+				fmt.Sprint(svc.Hosts),
+				strings.Join(svc.Endpoints, "\n"),
+			})
+		}
+		nmap.RenderTable(os.Stdout, headers, rows)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(servicesCmd)
+	addViewFilterFlags(servicesCmd)
+	servicesCmd.Flags().String("service", "", "Only show this service name (http, ssh, ...)")
+	servicesCmd.Flags().String("product", "", "Only show products containing this (nginx, OpenSSH, ...)")
+	servicesCmd.Flags().String("version", "", "Only show versions matching these constraints. Format: >=2.4.0,<2.4.50")
+	servicesCmd.Flags().String("group-by", nmap.GroupByVersion, fmt.Sprintf("Group services by %s, %s or %s", nmap.GroupByVersion, nmap.GroupByProduct, nmap.GroupByCPE))
+	servicesCmd.Flags().Bool("json", false, "Print JSON")
+}
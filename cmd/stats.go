@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/analog-substance/nex/pkg/nmap"
+	"github.com/spf13/cobra"
+)
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats file/glob [file/glob...]",
+	Short: "View headline numbers and charts for the scans",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		top, _ := cmd.Flags().GetInt("top")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		scans, err := nmap.ReadScans(getFiles(args))
+		check(err)
+
+		nmapView, viewOptions := newScansView(cmd, scans)
+		stats := nmapView.GetStats(viewOptions, top, scans)
+
+		if jsonOutput {
+			output, err := json.MarshalIndent(stats, "", "  ")
+			check(err)
+
+			fmt.Println(string(output))
+			return
+		}
+
+		nmap.RenderTable(os.Stdout, []string{"Stat", "Value"}, [][]string{
+			{"Hosts", fmt.Sprint(stats.Hosts)},
+			{"Up", fmt.Sprint(stats.Up)},
+			{"Down", fmt.Sprint(stats.Down)},
+			{"Hosts with open ports", fmt.Sprint(stats.HostsWithOpenPorts)},
+			{"Public hosts", fmt.Sprint(stats.PublicHosts)},
+			{"Private hosts", fmt.Sprint(stats.PrivateHosts)},
+			{"Open ports", fmt.Sprint(stats.OpenPorts)},
+			{"TCPWrapped ports", fmt.Sprintf("%d (%.1f%%)", stats.TCPWrapped, stats.TCPWrappedRatio*100)},
+			{"Scan time", formatSeconds(stats.TotalDuration)},
+		})
+
+		nmap.RenderBarChart(os.Stdout, "Up hosts by reason", stats.UpReasons, 40)
+		nmap.RenderBarChart(os.Stdout, "Down hosts by reason", stats.DownReasons, 40)
+		nmap.RenderBarChart(os.Stdout, "Top ports", stats.TopPorts, 40)
+		nmap.RenderBarChart(os.Stdout, "Top services", stats.TopServices, 40)
+		nmap.RenderBarChart(os.Stdout, "OS families", stats.OSFamilies, 40)
+
+		var rows [][]string
+		for _, scan := range stats.Scans {
+			rows = append(rows, []string{
+				scan.Path,
+				scan.Start.Format(time.DateTime),
+				formatSeconds(scan.Elapsed),
+				fmt.Sprint(scan.HostsUp),
+				fmt.Sprint(scan.HostsDown),
+				scan.Args,
+			})
+		}
+		nmap.RenderTable(os.Stdout, []string{"Scan", "Start", "Duration", "Up", "Down", "Args"}, rows)
+	},
+}
+
+func formatSeconds(seconds float64) string {
+	return (time.Duration(seconds * float64(time.Second))).Round(time.Second).String()
+}
+
+func init() {
+	RootCmd.AddCommand(statsCmd)
+	addViewFilterFlags(statsCmd)
+	statsCmd.Flags().Int("top", 20, "Number of ports and services to show, 0 for all")
+	statsCmd.Flags().Bool("json", false, "Print JSON")
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// subnetsCmd represents the subnets command
+var subnetsCmd = &cobra.Command{
+	Use:   "subnets file/glob [file/glob...]",
+	Short: "View live hosts and open ports per subnet",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		prefix, _ := cmd.Flags().GetInt("prefix")
+		prefix6, _ := cmd.Flags().GetInt("prefix6")
+		topServices, _ := cmd.Flags().GetInt("top-services")
+
+		if prefix < 0 || prefix > 32 {
+			check(fmt.Errorf("invalid IPv4 prefix length %d", prefix))
+		}
+
+		if prefix6 < 0 || prefix6 > 128 {
+			check(fmt.Errorf("invalid IPv6 prefix length %d", prefix6))
+		}
+
+		nmapView, viewOptions := newFilteredView(cmd, args)
+		subnets, err := nmapView.GetSubnetSummaries(viewOptions, prefix, prefix6, topServices)
+		check(err)
+
+		headers := []string{"Subnet", "Live Hosts", "Open Ports", "Top Services"}
+		var rows [][]string
+		for _, subnet := range subnets {
+			var services []string
+			for _, service := range subnet.TopServices {
+				services = append(services, service.String())
+			}
+
+			rows = append(rows, []string{
+				subnet.Subnet,
+				fmt.Sprint(subnet.LiveHosts),
+				fmt.Sprint(subnet.OpenPorts),
+				strings.Join(services, ", "),
+			})
+		}
+		printOutput(cmd, subnets, headers, rows)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(subnetsCmd)
+	addViewFilterFlags(subnetsCmd)
+	addOutputFlags(subnetsCmd)
+	subnetsCmd.Flags().Int("prefix", 24, "Prefix length used to group IPv4 addresses")
+	subnetsCmd.Flags().Int("prefix6", 64, "Prefix length used to group IPv6 addresses")
+	subnetsCmd.Flags().Int("top-services", 5, "Number of services to show per subnet, 0 for all")
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/analog-substance/nex/pkg/nmap"
+	"github.com/spf13/cobra"
+)
+
+var targetFormats = []string{"hostport", "ipport", "url", "msf", "service-files", "nmap"}
+
+// targetsCmd represents the targets command
+var targetsCmd = &cobra.Command{
+	Use:   "targets file/glob [file/glob...]",
+	Short: "Export open ports as target lists for other tools",
+	Long: `Export open ports as target lists for other tools.
+
+Formats:
+  hostport       host:port per line (nuclei, httpx, ffuf)
+  ipport         "ip port" per line
+  url            service://host:port per line (hydra, nuclei)
+  msf            Metasploit RHOSTS files per service and port, written to --output-dir
+  service-files  host:port files per service (ssh.txt, smb.txt), written to --output-dir
+  nmap           nmap -iL host lists per group of hosts with the same open ports,
+                 written to --output-dir with the matching nmap arguments printed.
+                 Each host is listed once, by its first hostname with --hostnames`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		outputDir, _ := cmd.Flags().GetString("output-dir")
+		useHostnames, _ := cmd.Flags().GetBool("hostnames")
+
+		nmapView, viewOptions := newFilteredView(cmd, args)
+
+		switch format {
+		case "hostport", "ipport", "url":
+			targets := nmapView.GetTargets(viewOptions, useHostnames && format != "ipport")
+			check(nmap.WriteTargets(os.Stdout, format, targets))
+
+		case "msf":
+			writeTargetFiles(outputDir, nmap.MSFTargetFiles(nmapView.GetTargets(viewOptions, false)))
+
+		case "service-files":
+			writeTargetFiles(outputDir, nmap.ServiceTargetFiles(nmapView.GetTargets(viewOptions, useHostnames)))
+
+		case "nmap":
+			files := map[string][]string{}
+			for i, group := range nmapView.GetScanGroups(viewOptions, useHostnames) {
+				name := fmt.Sprintf("group-%d.txt", i+1)
+				files[name] = group.Hosts
+
+				fmt.Println(strings.Join(group.NmapArgs(filepath.Join(outputDir, name)), " "))
+			}
+			writeTargetFiles(outputDir, files)
+
+		default:
+			check(fmt.Errorf("unknown format %q, expected one of: %s", format, strings.Join(targetFormats, ", ")))
+		}
+	},
+}
+
+// writeTargetFiles writes each list of lines to its file in dir.
+func writeTargetFiles(dir string, files map[string][]string) {
+	err := os.MkdirAll(dir, 0755)
+	check(err)
+
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		err = os.WriteFile(path, []byte(strings.Join(files[name], "\n")+"\n"), 0644)
+		check(err)
+
+		fmt.Fprintf(os.Stderr, "[+] Wrote %d targets to %s\n", len(files[name]), path)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(targetsCmd)
+	addViewFilterFlags(targetsCmd)
+	targetsCmd.Flags().StringP("format", "f", "hostport", fmt.Sprintf("Output format (%s)", strings.Join(targetFormats, ", ")))
+	targetsCmd.Flags().StringP("output-dir", "o", "targets", "Directory to write file based formats to")
+	targetsCmd.Flags().Bool("hostnames", false, "Include hostnames as targets alongside IP addresses")
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/analog-substance/nex/pkg/nmap"
+	"github.com/spf13/cobra"
+)
+
+// triageCmd represents the triage command
+var triageCmd = &cobra.Command{
+	Use:   "triage state target [target...]",
+	Short: "Set the triage state of hosts and ports",
+	Long: fmt.Sprintf(`Set the triage state of hosts and ports.
+
+States: %s
+
+Targets are an IP or hostname, optionally with a port and protocol, like
+10.0.0.1, web.example.com:443 or 10.0.0.1:53/udp. Ports without a state use
+the state of their host. Use "nex view --columns triage,tags" to see the
+states and "nex view --triage unreviewed" to only show ports left to review.`, strings.Join(nmap.TriageStates(), ", ")),
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		annotations := loadAnnotations(cmd)
+
+		now := time.Now()
+		for _, target := range args[1:] {
+			check(annotations.SetState(target, args[0], now))
+		}
+
+		check(annotations.Save())
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(triageCmd)
+	addTriageFileFlag(triageCmd)
+}
@@ -2,10 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
+
 	"github.com/analog-substance/nex/pkg/nmap"
 	"github.com/spf13/cobra"
-	"path/filepath"
-	"slices"
 )
 
 // viewCmd represents the view command
@@ -14,92 +14,29 @@ var viewCmd = &cobra.Command{
 	Short: "View Nmap XML scans in various forms",
 	Args:  cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		excludeThings, _ := cmd.Flags().GetStringSlice("exclude")
-		includeThings, _ := cmd.Flags().GetStringSlice("include")
-		includePublic, _ := cmd.Flags().GetBool("public")
-		includePrivate, _ := cmd.Flags().GetBool("private")
 		listIPs, _ := cmd.Flags().GetBool("ips")
 		listHostnames, _ := cmd.Flags().GetBool("hostnames")
 		jsonOutput, _ := cmd.Flags().GetBool("json")
-		openOnly, _ := cmd.Flags().GetBool("open")
-		upOnly, _ := cmd.Flags().GetBool("up")
-		noTCPWrapped, _ := cmd.Flags().GetBool("no-tcpwrapped")
-		excludePorts, _ := cmd.Flags().GetIntSlice("exclude-ports")
-		includePorts, _ := cmd.Flags().GetIntSlice("include-ports")
-
-		var files []string
-		for _, pattern := range args {
-			matches, err := filepath.Glob(pattern)
-			check(err)
-
-			files = append(files, matches...)
-		}
-
-		if len(files) == 0 {
-			check(fmt.Errorf("no files found"))
-		}
-
-		var opts []nmap.Option
-		run, err := nmap.XMLMerge(files, opts...)
-		check(err)
+		csvOutput, _ := cmd.Flags().GetBool("csv")
+		outputXML, _ := cmd.Flags().GetString("output-xml")
+		columns, _ := cmd.Flags().GetStringSlice("columns")
 
-		nmapView := nmap.NewNmapView(run)
-
-		nmapView.SetExcludePorts(excludePorts)
-		nmapView.SetIncludePorts(includePorts)
-
-		if len(excludeThings) > 0 || len(includeThings) > 0 {
-			nmapView.SetFilter(func(hostnames []string, ips []string) bool {
-				for _, exclude := range excludeThings {
-					if slices.Contains(hostnames, exclude) {
-						return false
-					}
-
-					if slices.Contains(ips, exclude) {
-						return false
-					}
-				}
-
-				if len(includeThings) > 0 {
-					for _, include := range includeThings {
-						if slices.Contains(hostnames, include) {
-							return true
-						}
-
-						if slices.Contains(ips, include) {
-							return true
-						}
-					}
-					return false
-				}
-
-				return true
-			})
-		}
+		nmapView, viewOptions := newFilteredView(cmd, args)
+		setCVEIndex(cmd, nmapView)
+		setAnnotations(cmd, nmapView)
+		check(nmapView.SetColumns(columns))
 
-		viewOptions := nmap.ViewOptions(0)
-		if includePublic {
-			viewOptions = viewOptions | nmap.ViewPublic
-		}
-
-		if includePrivate {
-			viewOptions = viewOptions | nmap.ViewPrivate
-		}
-
-		if upOnly {
-			viewOptions = viewOptions | nmap.ViewAliveHosts
-		}
-
-		if openOnly {
-			viewOptions = viewOptions | nmap.ViewOpenPorts
-		}
+		if outputXML != "" {
+			filteredRun, err := nmapView.GetRun(viewOptions)
+			check(err)
 
-		if noTCPWrapped {
-			viewOptions = viewOptions | nmap.IgnoreTCPWrapped
+			err = filteredRun.ToFile(outputXML)
+			check(err)
+			return
 		}
 
 		if jsonOutput {
-			err = nmapView.PrintJSON(viewOptions)
+			err := nmapView.PrintJSON(viewOptions)
 			check(err)
 			return
 		}
@@ -117,6 +54,12 @@ var viewCmd = &cobra.Command{
 		}
 
 		sortBy, _ := cmd.Flags().GetString("sort-by")
+		if csvOutput {
+			err := nmapView.PrintCSV(sortBy, viewOptions)
+			check(err)
+			return
+		}
+
 		// no options specified
 		nmapView.PrintTable(sortBy, viewOptions)
 
@@ -125,18 +68,15 @@ var viewCmd = &cobra.Command{
 
 func init() {
 	RootCmd.AddCommand(viewCmd)
+	addViewFilterFlags(viewCmd)
+	addCVEFlags(viewCmd)
+	addTriageFlags(viewCmd)
 	viewCmd.Flags().String("sort-by", "Hostnames;asc", "Sort by the specified column. Format: column[;(asc|dsc)]")
-	viewCmd.Flags().Bool("open", false, "Show only hosts with open ports")
-	viewCmd.Flags().Bool("up", false, "Show only hosts that are up")
 	viewCmd.Flags().Bool("hostnames", false, "Just list hostnames")
-	viewCmd.Flags().Bool("private", false, "Only show hosts with private IPs")
-	viewCmd.Flags().Bool("public", false, "Only show hosts with public IPs")
 	viewCmd.Flags().Bool("ips", false, "Just list IP addresses")
 	viewCmd.Flags().Bool("json", false, "Print JSON")
-	viewCmd.Flags().Bool("no-tcpwrapped", false, "Do not show TCPWrapped ports")
-	viewCmd.Flags().IntSlice("exclude-ports", []int{}, "Exclude these ports from the output")
-	viewCmd.Flags().IntSlice("include-ports", []int{}, "Include these ports from the output")
-	viewCmd.Flags().StringSlice("exclude", []string{}, "exclude")
-	viewCmd.Flags().StringSlice("include", []string{}, "include")
+	viewCmd.Flags().Bool("csv", false, "Print the table as CSV")
+	viewCmd.Flags().String("output-xml", "", "Write the filtered hosts and ports to this nmap XML file")
+	viewCmd.Flags().StringSlice("columns", []string{}, fmt.Sprintf("Extra table columns from script output, annotations and --dns-records, or host attributes from --enrich and --geoip: %s", strings.Join(nmap.ViewColumns(), ", ")))
 
 }
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/analog-substance/nex/pkg/nmap"
+	"github.com/spf13/cobra"
+)
+
+var vulnSortOrders = []string{nmap.VulnSortScore, nmap.VulnSortHost, nmap.VulnSortID}
+
+// vulnsCmd represents the vulns command
+var vulnsCmd = &cobra.Command{
+	Use:   "vulns file/glob [file/glob...]",
+	Short: "View vulnerabilities found by the vulners and vulscan scripts",
+	Long: `View vulnerabilities found by the vulners and vulscan scripts.
+
+Scans that didn't run those scripts can be matched against an offline NVD
+feed or CVE index with --cves, using the CPEs and versions from version
+detection.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		minCVSS, _ := cmd.Flags().GetFloat64("min-cvss")
+		allIDs, _ := cmd.Flags().GetBool("all-ids")
+		sortBy, _ := cmd.Flags().GetString("sort-by")
+
+		if !slices.Contains(vulnSortOrders, sortBy) {
+			check(fmt.Errorf("unknown sort order %q, expected one of: %v", sortBy, vulnSortOrders))
+		}
+
+		nmapView, viewOptions := newFilteredView(cmd, args)
+		setCVEIndex(cmd, nmapView)
+		vulns := nmapView.GetVulns(viewOptions, nmap.VulnOptions{
+			MinCVSS: minCVSS,
+			AllIDs:  allIDs,
+			SortBy:  sortBy,
+		})
+
+		headers := []string{"ID", "CVSS", "Exploit", "Endpoint", "Source"}
+		var rows [][]string
+		for _, vuln := range vulns {
+			exploit := ""
+			if vuln.IsExploit {
+				exploit = "yes"
+			}
+
+			rows = append(rows, []string{
+				vuln.ID,
+				fmt.Sprintf("%.1f", vuln.CVSS),
+				exploit,
+				vuln.Endpoint(),
+				vuln.Source,
+			})
+		}
+		printOutput(cmd, vulns, headers, rows)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(vulnsCmd)
+	addViewFilterFlags(vulnsCmd)
+	addCVEFlags(vulnsCmd)
+	addOutputFlags(vulnsCmd)
+	vulnsCmd.Flags().Float64("min-cvss", 0, "Only show vulnerabilities with at least this CVSS score")
+	vulnsCmd.Flags().Bool("all-ids", false, "Also show exploit and advisory IDs that are not CVEs")
+	vulnsCmd.Flags().String("sort-by", nmap.VulnSortScore, fmt.Sprintf("Sort by %s, %s or %s", nmap.VulnSortScore, nmap.VulnSortHost, nmap.VulnSortID))
+}
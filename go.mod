@@ -9,7 +9,9 @@ require (
 	github.com/analog-substance/arsenic v0.4.9
 	github.com/analog-substance/util v1.1.6
 	github.com/charmbracelet/lipgloss v1.1.1-0.20250404203927-76690c660834
+	github.com/oschwald/maxminddb-golang v1.13.1
 	github.com/spf13/cobra v1.9.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
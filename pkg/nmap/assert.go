@@ -0,0 +1,261 @@
+package nmap
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// AssertionResult is the outcome of a policy rule. The rule passes when it
+// matches nothing.
+type AssertionResult struct {
+	Rule       *Rule     `json:"rule"`
+	Violations []Finding `json:"violations"`
+}
+
+// Passed reports whether the rule matched nothing.
+func (r AssertionResult) Passed() bool {
+	return len(r.Violations) == 0
+}
+
+// LoadPolicy loads the rules of a policy file. Policy files use the format
+// of rule files, but rules without a severity are high and rules without a
+// title use their ID.
+func LoadPolicy(path string) ([]*Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := parseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse policy %s: %w", path, err)
+	}
+
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("policy %s has no rules", path)
+	}
+
+	var enabled []*Rule
+	for _, rule := range rules {
+		if rule.Disabled {
+			continue
+		}
+
+		if rule.Severity == "" {
+			rule.Severity = SeverityHigh
+		}
+
+		if rule.Title == "" {
+			rule.Title = rule.ID
+		}
+
+		err = rule.compile()
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule in %s: %w", path, err)
+		}
+		enabled = append(enabled, rule)
+	}
+	return enabled, nil
+}
+
+// Assert runs the policy rules against the hosts.
+func (v *View) Assert(policy []*Rule, options ViewOptions) []AssertionResult {
+	hosts := v.GetHostsWithOptions(options)
+
+	var results []AssertionResult
+	for _, rule := range policy {
+		result := AssertionResult{Rule: rule, Violations: []Finding{}}
+		for _, h := range hosts {
+			result.Violations = append(result.Violations, rule.findings(h)...)
+		}
+
+		sortFindings(result.Violations)
+		results = append(results, result)
+	}
+	return results
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the results as a JUnit XML report with a test case per
+// rule, so CI systems can show the violations.
+func WriteJUnit(out io.Writer, results []AssertionResult) error {
+	suite := junitTestSuite{Name: "nex assert"}
+	for _, result := range results {
+		testCase := junitTestCase{
+			Name:      fmt.Sprintf("%s: %s", result.Rule.ID, result.Rule.Title),
+			ClassName: "nex.policy",
+		}
+
+		if !result.Passed() {
+			var lines []string
+			for _, violation := range result.Violations {
+				lines = append(lines, fmt.Sprintf("%s %s", violation.Host, violation.Evidence))
+			}
+
+			testCase.Failure = &junitFailure{
+				Message: fmt.Sprintf("violations: %d", len(result.Violations)),
+				Type:    result.Rule.Severity,
+				Text:    strings.Join(lines, "\n"),
+			}
+			suite.Failures++
+		}
+
+		suite.Tests++
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	report := junitTestSuites{
+		Name:     suite.Name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	_, err := io.WriteString(out, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(report)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(out, "\n")
+	return err
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifRule struct {
+	ID                   string     `json:"id"`
+	Name                 string     `json:"name"`
+	ShortDescription     sarifText  `json:"shortDescription"`
+	FullDescription      *sarifText `json:"fullDescription,omitempty"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifText       `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+// sarifLevel maps severities to SARIF levels.
+func sarifLevel(severity string) string {
+	switch {
+	case SeverityRank(severity) >= SeverityRank(SeverityHigh):
+		return "error"
+	case SeverityRank(severity) == SeverityRank(SeverityMedium):
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// WriteSARIF writes the results as a SARIF 2.1.0 log. Violations are located
+// by logical locations naming the host and port, since there are no source
+// files to point at.
+func WriteSARIF(out io.Writer, results []AssertionResult) error {
+	rules := []sarifRule{}
+	sarifResults := []sarifResult{}
+	for _, result := range results {
+		rule := sarifRule{
+			ID:               result.Rule.ID,
+			Name:             result.Rule.ID,
+			ShortDescription: sarifText{Text: result.Rule.Title},
+		}
+
+		if result.Rule.Description != "" {
+			rule.FullDescription = &sarifText{Text: result.Rule.Description}
+		}
+
+		rule.DefaultConfiguration.Level = sarifLevel(result.Rule.Severity)
+		rules = append(rules, rule)
+
+		for _, violation := range result.Violations {
+			location := violation.Location()
+
+			sarifResults = append(sarifResults, sarifResult{
+				RuleID:  violation.RuleID,
+				Level:   sarifLevel(violation.Severity),
+				Message: sarifText{Text: fmt.Sprintf("%s: %s", result.Rule.Title, violation.Evidence)},
+				Locations: []sarifLocation{{
+					LogicalLocations: []sarifLogicalLocation{{
+						Name:               location,
+						FullyQualifiedName: location,
+						Kind:               "host",
+					}},
+				}},
+			})
+		}
+	}
+
+	log := map[string]any{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []any{
+			map[string]any{
+				"tool": map[string]any{
+					"driver": map[string]any{
+						"name":           "nex",
+						"informationUri": "https://github.com/analog-substance/nex",
+						"rules":          rules,
+					},
+				},
+				"results": sarifResults,
+			},
+		},
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
@@ -0,0 +1,139 @@
+package nmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writePolicy(t *testing.T, policy string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	err := os.WriteFile(path, []byte(policy), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAssert(t *testing.T) {
+	path := writePolicy(t, `rules:
+  - id: public-web-only
+    match:
+      public: true
+      not_ports: [80, 443]
+  - id: no-lab-ftp
+    severity: medium
+    match:
+      cidrs: [10.0.0.0/24]
+      ports: [21]
+  - id: no-telnet-lab
+    match:
+      cidrs: [10.0.0.0/24]
+      services: [telnet]
+  - id: ignored
+    disabled: true
+    match:
+      ports: [445]
+`)
+
+	policy, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := NewNmapView(rulesTestRun()).Assert(policy, 0)
+
+	want := map[string][]string{
+		"public-web-only": {"8.8.8.8:445/tcp", "8.8.8.8:2323/tcp", "8.8.8.8:6380/tcp"},
+		"no-lab-ftp":      {"10.0.0.1:21/tcp"},
+		"no-telnet-lab":   nil,
+	}
+
+	if len(results) != len(want) {
+		t.Fatalf("Assert() results = %d, want %d", len(results), len(want))
+	}
+
+	for _, result := range results {
+		var got []string
+		for _, violation := range result.Violations {
+			got = append(got, violation.Location())
+		}
+
+		if !slices.Equal(got, want[result.Rule.ID]) {
+			t.Errorf("Assert() %s violations = %v, want %v", result.Rule.ID, got, want[result.Rule.ID])
+		}
+
+		if result.Passed() != (len(want[result.Rule.ID]) == 0) {
+			t.Errorf("Assert() %s passed = %v", result.Rule.ID, result.Passed())
+		}
+	}
+
+	if results[0].Rule.Severity != SeverityHigh || results[0].Rule.Title != "public-web-only" {
+		t.Errorf("LoadPolicy() defaults = %q %q, want high and the id", results[0].Rule.Severity, results[0].Rule.Title)
+	}
+
+	var junit bytes.Buffer
+	err = WriteJUnit(&junit, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var suites junitTestSuites
+	err = xml.Unmarshal(junit.Bytes(), &suites)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if suites.Tests != 3 || suites.Failures != 2 {
+		t.Errorf("WriteJUnit() tests = %d failures = %d, want 3 and 2", suites.Tests, suites.Failures)
+	}
+
+	var sarif bytes.Buffer
+	err = WriteSARIF(&sarif, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []sarifResult `json:"results"`
+		} `json:"runs"`
+	}
+	err = json.Unmarshal(sarif.Bytes(), &log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Results) != 4 {
+		t.Fatalf("WriteSARIF() = %s", sarif.String())
+	}
+
+	if got := log.Runs[0].Results[3].Level; got != "warning" {
+		t.Errorf("WriteSARIF() level = %q, want warning", got)
+	}
+}
+
+func TestLoadPolicyInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+	}{
+		{name: "no rules", policy: "rules: []\n"},
+		{name: "missing id", policy: "rules:\n  - match:\n      ports: [22]\n"},
+		{name: "bad version", policy: "rules:\n  - id: x\n    match:\n      version: \"<\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadPolicy(writePolicy(t, tt.policy)); err == nil {
+				t.Error("LoadPolicy() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,397 @@
+package nmap
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"maps"
+	"math/big"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+// Asset columns used to match assets to hosts. Every other column is an
+// attribute of the asset.
+const (
+	AssetIP       = "ip"
+	AssetHostname = "hostname"
+	AssetCIDR     = "cidr"
+)
+
+// assetHeaders are the headers of the matching columns used when the column
+// mapping does not name them.
+var assetHeaders = map[string][]string{
+	AssetIP:       {"ip", "ips", "ip_address", "ipaddress", "address"},
+	AssetHostname: {"hostname", "hostnames", "host", "fqdn", "dns_name", "name"},
+	AssetCIDR:     {"cidr", "subnet", "network"},
+}
+
+// Asset is a row of an asset inventory.
+type Asset struct {
+	Row        int               `json:"row"`
+	Addresses  []string          `json:"addresses,omitempty"`
+	Hostnames  []string          `json:"hostnames,omitempty"`
+	Attributes map[string]string `json:"attributes"`
+
+	ranges []addrRange
+}
+
+// matchRank returns how specifically the asset matches the host: 0 for an
+// address match, 1 for a hostname match and 2 plus the size of the smallest
+// range for a range match. It returns nil if the asset does not match.
+func (a *Asset) matchRank(h *nmap.Host) *big.Int {
+	var best *big.Int
+	for _, hostAddr := range h.Addresses {
+		addr, err := netip.ParseAddr(hostAddr.Addr)
+		if err != nil {
+			continue
+		}
+
+		for _, r := range a.ranges {
+			if !r.contains(addr) {
+				continue
+			}
+
+			rank := big.NewInt(0)
+			if r.first != r.last {
+				rank.Add(rangeSize(r), big.NewInt(2))
+			}
+
+			if best == nil || rank.Cmp(best) < 0 {
+				best = rank
+			}
+		}
+	}
+
+	if best != nil && best.Sign() == 0 {
+		return best
+	}
+
+	for _, hostname := range h.Hostnames {
+		if slices.Contains(a.Hostnames, normalizeHostname(hostname.Name)) {
+			return big.NewInt(1)
+		}
+	}
+	return best
+}
+
+// rangeSize returns the number of addresses in the range.
+func rangeSize(r addrRange) *big.Int {
+	first := r.first.As16()
+	last := r.last.As16()
+
+	size := new(big.Int).Sub(new(big.Int).SetBytes(last[:]), new(big.Int).SetBytes(first[:]))
+	return size.Add(size, big.NewInt(1))
+}
+
+func normalizeHostname(hostname string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(hostname)), ".")
+}
+
+// normalizeHeader turns a CSV header like "Business Unit" into an attribute
+// key like "business_unit".
+func normalizeHeader(header string) string {
+	header = strings.ToLower(strings.TrimSpace(header))
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return '_'
+		}
+		return r
+	}, header)
+}
+
+// splitValues splits a cell holding several values, like
+// "10.0.0.1; 10.0.0.2".
+func splitValues(cell string) []string {
+	return strings.FieldsFunc(cell, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\n'
+	})
+}
+
+// ParseAssetMapping parses a column mapping like "ip=IP Address" or
+// "owner=Owner Name". The ip, hostname and cidr keys name the columns used
+// to match hosts, any other key names an attribute.
+func ParseAssetMapping(specs []string) (map[string]string, error) {
+	mapping := map[string]string{}
+	for _, spec := range specs {
+		key, column, ok := strings.Cut(spec, "=")
+		key = normalizeHeader(key)
+		column = strings.TrimSpace(column)
+		if !ok || key == "" || column == "" {
+			return nil, fmt.Errorf("invalid column mapping %q, expected key=column", spec)
+		}
+		mapping[key] = column
+	}
+	return mapping, nil
+}
+
+// AssetInventory is an asset inventory, like a CMDB export, used to add
+// owners, environments and other attributes to hosts.
+type AssetInventory struct {
+	Assets []*Asset
+	Keys   []string
+}
+
+// LoadAssets reads an asset inventory from a CSV file with a header row.
+// The mapping, as parsed by ParseAssetMapping, names the columns to use.
+// Without one, the IP, hostname and CIDR columns are found by common header
+// names and every other column is an attribute keyed by its normalized
+// header.
+func LoadAssets(path string, mapping map[string]string) (*AssetInventory, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the header of %s: %w", path, err)
+	}
+
+	columnIndex := func(column string) int {
+		return slices.IndexFunc(headers, func(header string) bool {
+			return strings.EqualFold(strings.TrimSpace(header), column) || normalizeHeader(header) == normalizeHeader(column)
+		})
+	}
+
+	columns := map[string]int{}
+	used := map[int]bool{}
+	for key, column := range mapping {
+		i := columnIndex(column)
+		if i == -1 {
+			return nil, fmt.Errorf("column %q of the %s mapping is not in %s", column, key, path)
+		}
+		columns[key] = i
+		used[i] = true
+	}
+
+	for key, names := range assetHeaders {
+		if _, ok := columns[key]; ok {
+			continue
+		}
+
+		for _, name := range names {
+			if i := columnIndex(name); i != -1 && !used[i] {
+				columns[key] = i
+				used[i] = true
+				break
+			}
+		}
+	}
+
+	_, hasIP := columns[AssetIP]
+	_, hasHostname := columns[AssetHostname]
+	_, hasCIDR := columns[AssetCIDR]
+	if !hasIP && !hasHostname && !hasCIDR {
+		return nil, fmt.Errorf("no IP, hostname or CIDR column in %s, map one with ip=column", path)
+	}
+
+	for i, header := range headers {
+		if !used[i] {
+			columns[normalizeHeader(header)] = i
+		}
+	}
+
+	inventory := &AssetInventory{}
+	for key := range columns {
+		if key != AssetIP && key != AssetHostname && key != AssetCIDR {
+			inventory.Keys = append(inventory.Keys, key)
+		}
+	}
+	slices.SortFunc(inventory.Keys, func(a, b string) int {
+		return cmp.Compare(columns[a], columns[b])
+	})
+
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s: %w", path, err)
+		}
+
+		cell := func(key string) string {
+			i, ok := columns[key]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		asset := &Asset{Row: row, Attributes: map[string]string{}}
+		for _, key := range []string{AssetIP, AssetCIDR} {
+			for _, value := range splitValues(cell(key)) {
+				r, ok := parseAddrRange(value)
+				if !ok {
+					return nil, fmt.Errorf("invalid IP or CIDR %q on row %d of %s", value, row, path)
+				}
+				asset.Addresses = append(asset.Addresses, value)
+				asset.ranges = append(asset.ranges, r)
+			}
+		}
+
+		for _, hostname := range splitValues(cell(AssetHostname)) {
+			asset.Hostnames = append(asset.Hostnames, normalizeHostname(hostname))
+		}
+
+		if len(asset.ranges) == 0 && len(asset.Hostnames) == 0 {
+			continue
+		}
+
+		for _, key := range inventory.Keys {
+			if value := cell(key); value != "" {
+				asset.Attributes[key] = value
+			}
+		}
+		inventory.Assets = append(inventory.Assets, asset)
+	}
+	return inventory, nil
+}
+
+// Match returns the assets matching the host, the most specific first:
+// assets with one of its addresses, then with one of its hostnames and then
+// from the smallest range to the largest.
+func (inv *AssetInventory) Match(h *nmap.Host) []*Asset {
+	type rankedAsset struct {
+		asset *Asset
+		rank  *big.Int
+	}
+
+	var ranked []rankedAsset
+	for _, asset := range inv.Assets {
+		if rank := asset.matchRank(h); rank != nil {
+			ranked = append(ranked, rankedAsset{asset: asset, rank: rank})
+		}
+	}
+
+	slices.SortStableFunc(ranked, func(a, b rankedAsset) int {
+		return a.rank.Cmp(b.rank)
+	})
+
+	var assets []*Asset
+	for _, r := range ranked {
+		assets = append(assets, r.asset)
+	}
+	return assets
+}
+
+// attributes returns the attributes of the assets matching the host, taking
+// each attribute from the most specific asset that has it.
+func (inv *AssetInventory) attributes(h *nmap.Host) map[string]string {
+	attributes := map[string]string{}
+	for _, asset := range inv.Match(h) {
+		for key, value := range asset.Attributes {
+			if _, ok := attributes[key]; !ok {
+				attributes[key] = value
+			}
+		}
+	}
+	return attributes
+}
+
+// SetAssets sets the asset inventory used to add attributes to hosts, shown
+// as columns and used by the where filter.
+func (v *View) SetAssets(inventory *AssetInventory) {
+	v.assets = inventory
+}
+
+// UnknownHost is a host up in the scans that is not in the asset inventory.
+type UnknownHost struct {
+	Host      string   `json:"host"`
+	Hostnames []string `json:"hostnames,omitempty"`
+	OpenPorts []string `json:"open_ports,omitempty"`
+}
+
+// AssetReport lists the hosts up in the scans but missing from the asset
+// inventory and the assets never seen up in the scans.
+type AssetReport struct {
+	Unknown []UnknownHost `json:"unknown"`
+	Unseen  []*Asset      `json:"unseen"`
+}
+
+// CompareAssets compares the hosts that are up to the asset inventory.
+func (v *View) CompareAssets(options ViewOptions) AssetReport {
+	report := AssetReport{Unknown: []UnknownHost{}, Unseen: []*Asset{}}
+	if v.assets == nil {
+		return report
+	}
+
+	seen := map[*Asset]bool{}
+	for _, h := range v.GetHostsWithOptions(options) {
+		if h.Status.State != "up" && !hasOpenPorts(h) {
+			continue
+		}
+
+		assets := v.assets.Match(h)
+		if len(assets) == 0 {
+			hostnames, _ := hostnamesAndIPs(h)
+			unknown := UnknownHost{Host: h.Addresses[0].Addr, Hostnames: hostnames}
+			for i := range h.Ports {
+				if portIsOpen(&h.Ports[i]) {
+					unknown.OpenPorts = append(unknown.OpenPorts, portDescription(&h.Ports[i]))
+				}
+			}
+			report.Unknown = append(report.Unknown, unknown)
+		}
+
+		for _, asset := range assets {
+			seen[asset] = true
+		}
+	}
+
+	for _, asset := range v.assets.Assets {
+		if !seen[asset] && v.keepsAsset(asset, options) {
+			report.Unseen = append(report.Unseen, asset)
+		}
+	}
+	return report
+}
+
+// keepsAsset reports whether the view filters would keep a host of the
+// asset, so assets that were filtered out are not reported as never seen.
+// Ranges are checked by their first address.
+func (v *View) keepsAsset(asset *Asset, options ViewOptions) bool {
+	h := &nmap.Host{Status: nmap.Status{State: "up"}}
+	for _, r := range asset.ranges {
+		h.Addresses = append(h.Addresses, nmap.Address{Addr: r.first.String()})
+	}
+
+	for _, hostname := range asset.Hostnames {
+		h.Hostnames = append(h.Hostnames, nmap.Hostname{Name: hostname})
+	}
+
+	if v.filter != nil && !v.filter(hostnamesAndIPs(h)) {
+		return false
+	}
+
+	hasPrivateIPs, hasPublicIPs := addressKinds(h)
+	if options&ViewPrivate != 0 && !hasPrivateIPs {
+		return false
+	}
+
+	if options&ViewPublic != 0 && !hasPublicIPs {
+		return false
+	}
+
+	// the asset's own attributes, not those of more specific assets
+	attributes := v.HostAttributes(h)
+	maps.Copy(attributes, asset.Attributes)
+	for _, condition := range v.where {
+		if !condition.matches(attributes) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,219 @@
+package nmap
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+func writeAssets(t *testing.T, data string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "assets.csv")
+	err := os.WriteFile(path, []byte(data), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func assetsTestView(t *testing.T) *View {
+	t.Helper()
+
+	run := rulesTestRun()
+	run.Hosts[0].Hostnames = []nmap.Hostname{{Name: "files.example.com"}}
+
+	path := writeAssets(t, `Name,Primary IP,Host,Owner,Environment,Business Unit
+files,,Files.example.com.,Storage,prod,IT
+lab,10.0.0.0/16,,Net Team,lab,IT
+lab-dmz,10.0.0.0-10.0.0.127,,,dmz,
+db01,10.0.0.50,,DBA,prod,Finance
+`)
+
+	inventory, err := LoadAssets(path, map[string]string{"ip": "Primary IP"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	view := NewNmapView(run)
+	view.SetAssets(inventory)
+	return view
+}
+
+func TestLoadAssets(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		mapping  map[string]string
+		wantKeys []string
+		wantErr  bool
+	}{
+		{
+			name:     "common headers",
+			data:     "IP Address,FQDN,Owner,Business Unit\n10.0.0.1,web.example.com.,Web,Retail\n",
+			wantKeys: []string{"owner", "business_unit"},
+		},
+		{
+			name:     "mapped attributes",
+			data:     "Addr,Team\n10.0.0.1,Web\n",
+			mapping:  map[string]string{"ip": "addr", "owner": "Team"},
+			wantKeys: []string{"owner"},
+		},
+		{
+			name:    "no matching column",
+			data:    "Owner\nWeb\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing mapped column",
+			data:    "IP,Owner\n10.0.0.1,Web\n",
+			mapping: map[string]string{"env": "Environment"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid IP",
+			data:    "IP,Owner\nweb01,Web\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inventory, err := LoadAssets(writeAssets(t, tt.data), tt.mapping)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadAssets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && !slices.Equal(inventory.Keys, tt.wantKeys) {
+				t.Errorf("LoadAssets() keys = %v, want %v", inventory.Keys, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestHostAttributes(t *testing.T) {
+	view := assetsTestView(t)
+	hosts := view.GetHosts()
+
+	want := []map[string]string{
+		{"name": "files", "owner": "Storage", "environment": "prod", "business_unit": "IT"},
+		{},
+	}
+	for i, h := range hosts {
+		if got := view.HostAttributes(h); !maps.Equal(got, want[i]) {
+			t.Errorf("HostAttributes(%s) = %v, want %v", h.Addresses[0].Addr, got, want[i])
+		}
+	}
+
+	report := view.CompareAssets(0)
+	if len(report.Unknown) != 1 || report.Unknown[0].Host != "8.8.8.8" || len(report.Unknown[0].OpenPorts) != 3 {
+		t.Errorf("CompareAssets() unknown = %+v", report.Unknown)
+	}
+
+	if len(report.Unseen) != 1 || report.Unseen[0].Attributes["name"] != "db01" {
+		t.Errorf("CompareAssets() unseen = %+v", report.Unseen)
+	}
+}
+
+func TestCompareAssetsFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		options ViewOptions
+		include []string
+		where   string
+		want    []string
+	}{
+		{name: "no filters", want: []string{"db01"}},
+		{name: "public hosts", options: ViewPublic},
+		{name: "private hosts", options: ViewPrivate, want: []string{"db01"}},
+		{name: "included hosts", include: []string{"8.8.8.8"}},
+		{name: "matching where", where: "business_unit == finance", want: []string{"db01"}},
+		{name: "other where", where: "owner == storage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			view := assetsTestView(t)
+			if tt.include != nil {
+				view.SetFilter(HostListFilter(tt.include, nil))
+			}
+
+			if tt.where != "" {
+				condition, err := ParseWhereCondition(tt.where)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				err = view.SetWhere([]WhereCondition{condition})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var got []string
+			for _, asset := range view.CompareAssets(tt.options).Unseen {
+				got = append(got, asset.Attributes["name"])
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("CompareAssets() unseen = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWhere(t *testing.T) {
+	tests := []struct {
+		conditions []string
+		want       []string
+		wantErr    bool
+	}{
+		{conditions: []string{"environment == PROD"}, want: []string{"10.0.0.1"}},
+		{conditions: []string{"owner == ''"}, want: []string{"8.8.8.8"}},
+		{conditions: []string{"business_unit=~^i", "owner != storage"}},
+		{conditions: []string{"owner !~ ^St"}, want: []string{"8.8.8.8"}},
+		{conditions: []string{"owner"}, wantErr: true},
+		{conditions: []string{"== prod"}, wantErr: true},
+		{conditions: []string{"owner =~ ("}, wantErr: true},
+		{conditions: []string{"env == prod"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.conditions[0], func(t *testing.T) {
+			var conditions []WhereCondition
+			for _, condition := range tt.conditions {
+				parsed, err := ParseWhereCondition(condition)
+				if err != nil {
+					if !tt.wantErr {
+						t.Fatal(err)
+					}
+					return
+				}
+				conditions = append(conditions, parsed)
+			}
+
+			view := assetsTestView(t)
+			err := view.SetWhere(conditions)
+			if err != nil {
+				if !tt.wantErr {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			if tt.wantErr {
+				t.Fatal("SetWhere() error = nil, want an error")
+			}
+
+			var got []string
+			for _, h := range view.GetHostsWithOptions(0) {
+				got = append(got, h.Addresses[0].Addr)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GetHostsWithOptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
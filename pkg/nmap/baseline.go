@@ -0,0 +1,317 @@
+package nmap
+
+import (
+	"cmp"
+	"fmt"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Ullaakut/nmap/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of differences between scans and a baseline.
+const (
+	BaselineUnexpected = "unexpected"
+	BaselineMissing    = "missing"
+	BaselineChanged    = "changed"
+	BaselineExpired    = "expired"
+)
+
+// baselineDateLayout is the layout of baseline expiry dates.
+const baselineDateLayout = "2006-01-02"
+
+// BaselineEntry is an expected open port on a host, or on hosts in a
+// subnet. The target is an IP, CIDR, IP range or hostname. The product is
+// compared without its version, so patching a service doesn't change it. An
+// entry with an expiry date is valid through that date.
+type BaselineEntry struct {
+	Target   string `yaml:"target" json:"target"`
+	Port     uint16 `yaml:"port" json:"port"`
+	Protocol string `yaml:"protocol,omitempty" json:"protocol,omitempty"`
+	Service  string `yaml:"service,omitempty" json:"service,omitempty"`
+	Product  string `yaml:"product,omitempty" json:"product,omitempty"`
+	Expires  string `yaml:"expires,omitempty" json:"expires,omitempty"`
+	Note     string `yaml:"note,omitempty" json:"note,omitempty"`
+
+	addrs    addrRange
+	hostname string
+	expires  time.Time
+}
+
+func (e *BaselineEntry) compile() error {
+	e.Target = strings.TrimSpace(e.Target)
+	if e.Target == "" {
+		return fmt.Errorf("entry for port %d is missing a target", e.Port)
+	}
+
+	if e.Port == 0 {
+		return fmt.Errorf("entry for %s is missing a port", e.Target)
+	}
+
+	e.Protocol = strings.ToLower(cmp.Or(e.Protocol, ProtocolTCP))
+	if !slices.Contains(protocols, e.Protocol) {
+		return fmt.Errorf("entry for %s has unknown protocol %q", e.Target, e.Protocol)
+	}
+
+	r, ok := parseAddrRange(e.Target)
+	if ok {
+		e.addrs = r
+	} else {
+		e.hostname = strings.ToLower(e.Target)
+	}
+
+	e.expires = time.Time{}
+	if e.Expires != "" {
+		expires, err := time.Parse(baselineDateLayout, e.Expires)
+		if err != nil {
+			return fmt.Errorf("entry for %s has invalid expiry date %q, expected YYYY-MM-DD", e.Target, e.Expires)
+		}
+		e.expires = expires
+	}
+	return nil
+}
+
+// expired reports whether the entry expired before now.
+func (e *BaselineEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires.AddDate(0, 0, 1))
+}
+
+func (e *BaselineEntry) matchesHost(h *nmap.Host) bool {
+	if e.hostname != "" {
+		return slices.ContainsFunc(h.Hostnames, func(hostname nmap.Hostname) bool {
+			return strings.EqualFold(hostname.Name, e.hostname)
+		})
+	}
+
+	for _, a := range h.Addresses {
+		addr, err := netip.ParseAddr(a.Addr)
+		if err == nil && e.addrs.contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *BaselineEntry) matchesPort(port *nmap.Port) bool {
+	key := newPortKey(*port)
+	return key.id == e.Port && key.protocol == e.Protocol
+}
+
+// serviceChanged reports whether the service on the port is not the one the
+// entry expects.
+func (e *BaselineEntry) serviceChanged(port *nmap.Port) bool {
+	if e.Service != "" && !strings.EqualFold(e.Service, port.Service.Name) {
+		return true
+	}
+	return e.Product != "" && !strings.EqualFold(e.Product, port.Service.Product)
+}
+
+// expected describes the service the entry expects.
+func (e *BaselineEntry) expected() string {
+	return strings.TrimSpace(fmt.Sprintf("%s %s", e.Service, e.Product))
+}
+
+// Baseline is the list of approved open ports.
+type Baseline struct {
+	Created time.Time        `yaml:"created" json:"created"`
+	Entries []*BaselineEntry `yaml:"entries" json:"entries"`
+}
+
+// ReadBaseline reads a baseline file.
+func ReadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	baseline := &Baseline{}
+	err = yaml.Unmarshal(data, baseline)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse baseline %s: %w", path, err)
+	}
+
+	for _, entry := range baseline.Entries {
+		err = entry.compile()
+		if err != nil {
+			return nil, fmt.Errorf("invalid baseline %s: %w", path, err)
+		}
+	}
+	return baseline, nil
+}
+
+// Write saves the baseline as YAML.
+func (b *Baseline) Write(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	encoder := yaml.NewEncoder(f)
+	encoder.SetIndent(2)
+	err = encoder.Encode(b)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.Close()
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// CreateBaseline snapshots the open ports of the hosts into a baseline. The
+// note and expiry date, if not empty, are set on every entry.
+func (v *View) CreateBaseline(options ViewOptions, note string, expires string, now time.Time) (*Baseline, error) {
+	baseline := &Baseline{Created: now.UTC().Truncate(time.Second)}
+	for _, h := range v.GetHostsWithOptions(options) {
+		for i := range h.Ports {
+			port := &h.Ports[i]
+			if !portIsOpen(port) {
+				continue
+			}
+
+			entry := &BaselineEntry{
+				Target:   h.Addresses[0].Addr,
+				Port:     port.ID,
+				Protocol: newPortKey(*port).protocol,
+				Service:  port.Service.Name,
+				Product:  port.Service.Product,
+				Expires:  expires,
+				Note:     note,
+			}
+
+			err := entry.compile()
+			if err != nil {
+				return nil, err
+			}
+			baseline.Entries = append(baseline.Entries, entry)
+		}
+	}
+	return baseline, nil
+}
+
+// BaselineChange is a difference between the scans and a baseline.
+type BaselineChange struct {
+	Kind      string   `json:"kind"`
+	Host      string   `json:"host"`
+	Hostnames []string `json:"hostnames,omitempty"`
+	Port      uint16   `json:"port"`
+	Protocol  string   `json:"protocol"`
+	Expected  string   `json:"expected,omitempty"`
+	Actual    string   `json:"actual,omitempty"`
+	Expires   string   `json:"expires,omitempty"`
+	Note      string   `json:"note,omitempty"`
+}
+
+// CheckBaseline compares the open ports of the hosts to the baseline. It
+// reports open ports without an entry as unexpected, or as expired if their
+// only entries have expired, entries without an open port as missing and
+// ports running a different service than their entry as changed. Entries
+// are only missing when the coverage shows their port was scanned on a host
+// the view filters keep, so partial scans don't report everything else.
+func (v *View) CheckBaseline(baseline *Baseline, coverage map[string]Coverage, options ViewOptions, now time.Time) []BaselineChange {
+	var changes []BaselineChange
+	seen := map[*BaselineEntry]bool{}
+	for _, h := range v.GetHostsWithOptions(options) {
+		hostnames, _ := hostnamesAndIPs(h)
+
+		var hostEntries []*BaselineEntry
+		for _, entry := range baseline.Entries {
+			if entry.matchesHost(h) {
+				hostEntries = append(hostEntries, entry)
+			}
+		}
+
+		for i := range h.Ports {
+			port := &h.Ports[i]
+			if !portIsOpen(port) {
+				continue
+			}
+
+			var active, expired []*BaselineEntry
+			for _, entry := range hostEntries {
+				if !entry.matchesPort(port) {
+					continue
+				}
+
+				seen[entry] = true
+				if entry.expired(now) {
+					expired = append(expired, entry)
+				} else {
+					active = append(active, entry)
+				}
+			}
+
+			change := BaselineChange{
+				Host:      h.Addresses[0].Addr,
+				Hostnames: hostnames,
+				Port:      port.ID,
+				Protocol:  newPortKey(*port).protocol,
+				Actual:    strings.TrimSpace(fmt.Sprintf("%s %s", port.Service.Name, serviceProduct(port.Service))),
+			}
+
+			switch {
+			case len(active) == 0 && len(expired) == 0:
+				change.Kind = BaselineUnexpected
+			case len(active) == 0:
+				change.Kind = BaselineExpired
+				change.Expected = expired[0].expected()
+				change.Expires = expired[0].Expires
+				change.Note = expired[0].Note
+			case !slices.ContainsFunc(active, func(entry *BaselineEntry) bool { return !entry.serviceChanged(port) }):
+				change.Kind = BaselineChanged
+				change.Expected = active[0].expected()
+				change.Expires = active[0].Expires
+				change.Note = active[0].Note
+			default:
+				continue
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	var scanned []*nmap.Host
+	for _, h := range v.GetHosts() {
+		if v.keepsHost(h, options) {
+			scanned = append(scanned, h)
+		}
+	}
+
+	for _, entry := range baseline.Entries {
+		if seen[entry] || entry.expired(now) || !v.keepsPortID(entry.Port) {
+			continue
+		}
+
+		covered := slices.ContainsFunc(scanned, func(h *nmap.Host) bool {
+			return entry.matchesHost(h) && hostCoverage(h, coverage).Covers(entry.Protocol, entry.Port)
+		})
+		if !covered {
+			continue
+		}
+
+		changes = append(changes, BaselineChange{
+			Kind:     BaselineMissing,
+			Host:     entry.Target,
+			Port:     entry.Port,
+			Protocol: entry.Protocol,
+			Expected: entry.expected(),
+			Expires:  entry.Expires,
+			Note:     entry.Note,
+		})
+	}
+
+	slices.SortStableFunc(changes, func(a, b BaselineChange) int {
+		return cmp.Or(
+			strings.Compare(a.Host, b.Host),
+			cmp.Compare(a.Port, b.Port),
+			cmp.Compare(protocolIndex(a.Protocol), protocolIndex(b.Protocol)),
+		)
+	})
+	return changes
+}
@@ -0,0 +1,143 @@
+package nmap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCheckBaseline(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// 10.0.0.1 was only scanned on the first 1000 TCP ports and UDP 445
+	coverage := map[string]Coverage{
+		"10.0.0.1": {ProtocolTCP: parsePortRanges("1-1000"), ProtocolUDP: parsePortRanges("445")},
+		"8.8.8.8":  {ProtocolTCP: parsePortRanges("1-65535")},
+	}
+
+	tests := []struct {
+		name    string
+		entries string
+		options ViewOptions
+		want    []string
+	}{
+		{
+			name: "matching baseline",
+			entries: `
+  - {target: 10.0.0.1, port: 21, service: ftp}
+  - {target: 10.0.0.0/24, port: 445}
+  - {target: 8.8.8.8, port: 445}
+  - {target: 8.8.8.8, port: 2323, service: telnet, expires: 2026-06-01}
+  - {target: 8.8.8.8, port: 6380, service: redis, product: Redis}
+`,
+		},
+		{
+			name: "differences",
+			entries: `
+  - {target: 10.0.0.1, port: 21, service: ssh}
+  - {target: 10.0.0.1, port: 22, note: jump host}
+  - {target: 10.0.0.1, port: 445, protocol: udp}
+  - {target: 8.8.8.8, port: 445}
+  - {target: 8.8.8.8, port: 2323, expires: 2026-05-31}
+  - {target: 8.8.8.8, port: 6379, expires: 2026-05-31}
+  - {target: 8.8.8.8, port: 6380, product: Valkey}
+  - {target: 10.0.0.1, port: 3306}
+  - {target: 10.0.0.1, port: 53, protocol: udp}
+  - {target: 10.0.0.9, port: 22}
+`,
+			want: []string{
+				"changed 10.0.0.1:21/tcp",
+				"missing 10.0.0.1:22/tcp",
+				"unexpected 10.0.0.1:445/tcp",
+				"missing 10.0.0.1:445/udp",
+				"expired 8.8.8.8:2323/tcp",
+				"changed 8.8.8.8:6380/tcp",
+			},
+		},
+		{
+			name: "filtered out hosts",
+			entries: `
+  - {target: 10.0.0.1, port: 22}
+  - {target: 8.8.8.8, port: 22}
+  - {target: 8.8.8.8, port: 445}
+  - {target: 8.8.8.8, port: 2323}
+  - {target: 8.8.8.8, port: 6380}
+`,
+			options: ViewPublic,
+			want:    []string{"missing 8.8.8.8:22/tcp"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "baseline.yaml")
+			err := os.WriteFile(path, []byte("entries:"+tt.entries), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			baseline, err := ReadBaseline(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			run := rulesTestRun()
+			run.Hosts[1].Ports[1].Service.Product = "Redis"
+			run.Hosts[1].Ports[1].Service.Version = "7.0.11"
+
+			var got []string
+			for _, change := range NewNmapView(run).CheckBaseline(baseline, coverage, tt.options, now) {
+				got = append(got, fmt.Sprintf("%s %s:%d/%s", change.Kind, change.Host, change.Port, change.Protocol))
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("CheckBaseline() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateBaseline(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	run := rulesTestRun()
+	run.Hosts[1].Ports[1].Service.Product = "Redis"
+	run.Hosts[1].Ports[1].Service.Version = "7.0.11"
+	view := NewNmapView(run)
+
+	baseline, err := view.CreateBaseline(0, "approved", "2026-12-31", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "baseline.yaml")
+	err = baseline.Write(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	baseline, err = ReadBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(baseline.Entries) != 5 || baseline.Entries[0].Note != "approved" {
+		t.Errorf("ReadBaseline() entries = %d", len(baseline.Entries))
+	}
+
+	i := slices.IndexFunc(baseline.Entries, func(entry *BaselineEntry) bool { return entry.Port == 6380 })
+	if i == -1 || baseline.Entries[i].Product != "Redis" {
+		t.Errorf("CreateBaseline() entries = %v, want product Redis without its version on 6380", baseline.Entries)
+	}
+
+	// a patched service is not a change
+	run.Hosts[1].Ports[1].Service.Version = "7.0.12"
+	if changes := NewNmapView(run).CheckBaseline(baseline, nil, 0, now); len(changes) != 0 {
+		t.Errorf("CheckBaseline() = %v, want no changes", changes)
+	}
+
+	if _, err := view.CreateBaseline(0, "", "31/12/2026", now); err == nil {
+		t.Error("CreateBaseline() error = nil, want an invalid expiry error")
+	}
+}
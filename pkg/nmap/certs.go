@@ -0,0 +1,144 @@
+package nmap
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"maps"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+// Certificate flags.
+const (
+	CertExpired       = "expired"
+	CertExpiring      = "expiring"
+	CertSelfSigned    = "self-signed"
+	CertWeakKey       = "weak-key"
+	CertWeakSignature = "weak-signature"
+)
+
+// minKeyBits is the smallest key size that is not considered weak for each
+// key type.
+var minKeyBits = map[string]int{
+	"rsa": 2048,
+	"dsa": 2048,
+	"ec":  224,
+}
+
+// Certificate is a certificate and every endpoint it was found on.
+type Certificate struct {
+	*SSLCert
+	Endpoints []string `json:"endpoints"`
+	Flags     []string `json:"flags"`
+}
+
+// CertOptions sets when certificates are flagged as expiring.
+type CertOptions struct {
+	Now            time.Time
+	ExpiringWithin time.Duration
+}
+
+// GetCertificates returns the certificates from the ssl-cert output of the
+// open ports, soonest to expire first. Endpoints sharing a certificate are
+// grouped together.
+func (v *View) GetCertificates(options ViewOptions, certOptions CertOptions) []Certificate {
+	certs := map[string]*Certificate{}
+	var keys []string
+	for _, h := range v.GetHostsWithOptions(options | ViewOpenPorts) {
+		for _, port := range h.Ports {
+			script, ok := findScript(port.Scripts, "ssl-cert")
+			if !ok {
+				continue
+			}
+
+			sslCert, err := ParseSSLCert(script)
+			if err != nil {
+				log.Printf("[!] Unable to parse the ssl-cert output for %s port %d/%s: %s", h.Addresses[0].Addr, port.ID, port.Protocol, err)
+				continue
+			}
+
+			key := certKey(sslCert)
+			cert, ok := certs[key]
+			if !ok {
+				cert = &Certificate{
+					SSLCert: sslCert,
+					Flags:   certFlags(sslCert, certOptions),
+				}
+				certs[key] = cert
+				keys = append(keys, key)
+			}
+
+			for _, endpoint := range certEndpoints(h, port) {
+				if !slices.Contains(cert.Endpoints, endpoint) {
+					cert.Endpoints = append(cert.Endpoints, endpoint)
+				}
+			}
+		}
+	}
+
+	var certificates []Certificate
+	for _, key := range keys {
+		certificates = append(certificates, *certs[key])
+	}
+
+	slices.SortStableFunc(certificates, func(a, b Certificate) int {
+		return a.NotAfter.Compare(b.NotAfter)
+	})
+	return certificates
+}
+
+// certKey identifies a certificate by its fingerprint, or by its details
+// when nmap did not report one.
+func certKey(cert *SSLCert) string {
+	fingerprint := cmp.Or(cert.SHA256, cert.SHA1, cert.MD5)
+	if fingerprint != "" {
+		return fingerprint
+	}
+	return fmt.Sprintf("%v|%v|%v|%s", cert.Subject, cert.Issuer, cert.SANs, cert.NotAfter)
+}
+
+func certEndpoints(h *nmap.Host, port nmap.Port) []string {
+	var endpoints []string
+	for _, addr := range h.Addresses {
+		if addr.AddrType == "mac" {
+			continue
+		}
+		endpoints = append(endpoints, net.JoinHostPort(addr.Addr, fmt.Sprint(port.ID)))
+	}
+	return endpoints
+}
+
+func certFlags(cert *SSLCert, options CertOptions) []string {
+	now := options.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	flags := []string{}
+	if !cert.NotAfter.IsZero() {
+		if now.After(cert.NotAfter) {
+			flags = append(flags, CertExpired)
+		} else if now.Add(options.ExpiringWithin).After(cert.NotAfter) {
+			flags = append(flags, CertExpiring)
+		}
+	}
+
+	if len(cert.Subject) > 0 && maps.Equal(cert.Subject, cert.Issuer) {
+		flags = append(flags, CertSelfSigned)
+	}
+
+	if minBits, ok := minKeyBits[strings.ToLower(cert.KeyType)]; ok && cert.KeyBits > 0 && cert.KeyBits < minBits {
+		flags = append(flags, CertWeakKey)
+	}
+
+	algo := strings.ToLower(cert.SignatureAlgorithm)
+	if strings.HasPrefix(algo, "md5") || strings.HasPrefix(algo, "md2") || strings.HasPrefix(algo, "sha1") {
+		flags = append(flags, CertWeakSignature)
+	}
+	return flags
+}
@@ -0,0 +1,71 @@
+package nmap
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+func certScript(cn string, issuer string, bits int, notAfter string, sha256 string) nmap.Script {
+	return nmap.Script{
+		ID: "ssl-cert",
+		Tables: []nmap.Table{
+			{Key: "subject", Elements: []nmap.Element{{Key: "commonName", Value: cn}}},
+			{Key: "issuer", Elements: []nmap.Element{{Key: "commonName", Value: issuer}}},
+			{Key: "pubkey", Elements: []nmap.Element{{Key: "type", Value: "rsa"}, {Key: "bits", Value: fmt.Sprint(bits)}}},
+			{Key: "validity", Elements: []nmap.Element{{Key: "notAfter", Value: notAfter}}},
+		},
+		Elements: []nmap.Element{
+			{Key: "sig_algo", Value: "sha256WithRSAEncryption"},
+			{Key: "sha256", Value: sha256},
+		},
+	}
+}
+
+func certPort(id uint16, script nmap.Script) nmap.Port {
+	return nmap.Port{ID: id, Protocol: "tcp", State: nmap.State{State: "open"}, Scripts: []nmap.Script{script}}
+}
+
+func TestGetCertificates(t *testing.T) {
+	shared := certScript("www.example.com", "Example CA", 2048, "2025-03-01T00:00:00", "aa")
+	run := &nmap.Run{Hosts: []nmap.Host{
+		{
+			Status:    nmap.Status{State: "up"},
+			Addresses: []nmap.Address{{Addr: "10.0.0.1", AddrType: "ipv4"}},
+			Ports: []nmap.Port{
+				certPort(443, shared),
+				certPort(8443, certScript("device.local", "device.local", 1024, "2030-01-01T00:00:00", "bb")),
+			},
+		},
+		{
+			Status:    nmap.Status{State: "up"},
+			Addresses: []nmap.Address{{Addr: "10.0.0.2", AddrType: "ipv4"}, {Addr: "2001:db8::2", AddrType: "ipv6"}},
+			Ports: []nmap.Port{
+				certPort(443, shared),
+				certPort(993, certScript("mail.example.com", "Example CA", 2048, "2024-12-01T00:00:00", "cc")),
+			},
+		},
+	}}
+
+	certs := NewNmapView(run).GetCertificates(0, CertOptions{
+		Now:            time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		ExpiringWithin: 90 * 24 * time.Hour,
+	})
+
+	var got []string
+	for _, cert := range certs {
+		got = append(got, fmt.Sprintf("%s %v %v", cert.CommonName(), cert.Flags, cert.Endpoints))
+	}
+
+	want := []string{
+		"mail.example.com [expired] [10.0.0.2:993 [2001:db8::2]:993]",
+		"www.example.com [expiring] [10.0.0.1:443 10.0.0.2:443 [2001:db8::2]:443]",
+		"device.local [self-signed weak-key] [10.0.0.1:8443]",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("GetCertificates() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,196 @@
+package nmap
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+// column is an extra view table column built from parsed script output or
+// annotations.
+type column struct {
+	header string
+	value  func(v *View, h *nmap.Host) []string
+}
+
+var viewColumns = map[string]column{
+	"title": portColumn("Title", "http-title", func(script nmap.Script) string {
+		return ParseHTTPTitle(script).Title
+	}),
+	"server": portColumn("Server", "http-server-header", func(script nmap.Script) string {
+		return ParseHTTPServerHeader(script).Server
+	}),
+	"cert_cn": portColumn("Cert CN", "ssl-cert", func(script nmap.Script) string {
+		cert, err := ParseSSLCert(script)
+		if err != nil {
+			return ""
+		}
+		return cert.CommonName()
+	}),
+	"cert_expiry": portColumn("Cert Expiry", "ssl-cert", func(script nmap.Script) string {
+		cert, err := ParseSSLCert(script)
+		if err != nil || cert.NotAfter.IsZero() {
+			return ""
+		}
+		return cert.NotAfter.Format("2006-01-02")
+	}),
+	"ssh_keys": portColumn("SSH Keys", "ssh-hostkey", func(script nmap.Script) string {
+		keys, err := ParseSSHHostKeys(script)
+		if err != nil {
+			return ""
+		}
+
+		var types []string
+		for _, key := range keys {
+			types = append(types, fmt.Sprintf("%s %d", key.Type, key.Bits))
+		}
+		return strings.Join(types, ", ")
+	}),
+	"rdp_name": portColumn("RDP Name", "rdp-ntlm-info", func(script nmap.Script) string {
+		return ParseRDPNTLMInfo(script).DNSComputerName
+	}),
+	"smb_os": hostColumn("SMB OS", "smb-os-discovery", func(script nmap.Script) string {
+		return ParseSMBOSDiscovery(script).OS
+	}),
+	"smb_signing": hostColumn("SMB Signing", "smb-security-mode", func(script nmap.Script) string {
+		return ParseSMBSecurityMode(script).MessageSigning
+	}),
+	"triage":    {header: "Triage", value: triageColumn},
+	"tags":      {header: "Tags", value: tagsColumn},
+	"dns_names": {header: "DNS Names", value: dnsNamesColumn},
+}
+
+// ViewColumns returns the names of the extra columns the view table can
+// show.
+func ViewColumns() []string {
+	var names []string
+	for name := range viewColumns {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// SetColumns sets the extra columns shown by PrintTable. Besides the
+// ViewColumns, the keys of host attributes, like the columns of an asset
+// inventory, can be shown.
+func (v *View) SetColumns(columns []string) error {
+	for _, name := range columns {
+		if _, ok := viewColumns[name]; !ok && !slices.Contains(v.attributeKeys(), name) {
+			names := append(ViewColumns(), v.attributeKeys()...)
+			return fmt.Errorf("unknown column %q, expected one of: %s", name, strings.Join(names, ", "))
+		}
+	}
+
+	v.columns = columns
+	return nil
+}
+
+// column returns the column with the name, either one of the ViewColumns or
+// a host attribute.
+func (v *View) column(name string) column {
+	if c, ok := viewColumns[name]; ok {
+		return c
+	}
+
+	return column{
+		header: name,
+		value: func(v *View, h *nmap.Host) []string {
+			if value := v.HostAttributes(h)[name]; value != "" {
+				return []string{value}
+			}
+			return nil
+		},
+	}
+}
+
+// portColumn shows a value from a port script as "port: value" lines, one for
+// each open port that ran the script.
+func portColumn(header string, scriptID string, value func(script nmap.Script) string) column {
+	return column{
+		header: header,
+		value: func(v *View, h *nmap.Host) []string {
+			var values []string
+			for _, port := range h.Ports {
+				if !portIsOpen(&port) {
+					continue
+				}
+
+				script, ok := findScript(port.Scripts, scriptID)
+				if !ok {
+					continue
+				}
+
+				if v := value(script); v != "" {
+					values = append(values, fmt.Sprintf("%d: %s", port.ID, v))
+				}
+			}
+			return values
+		},
+	}
+}
+
+// hostColumn shows a value from a host script.
+func hostColumn(header string, scriptID string, value func(script nmap.Script) string) column {
+	return column{
+		header: header,
+		value: func(v *View, h *nmap.Host) []string {
+			script, ok := findScript(h.HostScripts, scriptID)
+			if !ok {
+				return nil
+			}
+
+			if v := value(script); v != "" {
+				return []string{v}
+			}
+			return nil
+		},
+	}
+}
+
+// triageColumn shows the triage state of the host, if it has one, and of
+// each open port.
+func triageColumn(v *View, h *nmap.Host) []string {
+	var values []string
+	if hostAnnotation := v.annotations.hostAnnotation(h); hostAnnotation != nil && hostAnnotation.State != "" {
+		values = append(values, fmt.Sprintf("host: %s", hostAnnotation.State))
+	}
+
+	for _, port := range h.Ports {
+		if portIsOpen(&port) {
+			values = append(values, fmt.Sprintf("%s: %s", triagePortKey(&port), v.annotations.portState(h, &port)))
+		}
+	}
+	return values
+}
+
+// tagsColumn shows the tags of the host and its open ports.
+func tagsColumn(v *View, h *nmap.Host) []string {
+	var values []string
+	if hostAnnotation := v.annotations.hostAnnotation(h); hostAnnotation != nil && len(hostAnnotation.Tags) > 0 {
+		values = append(values, fmt.Sprintf("host: %s", strings.Join(hostAnnotation.Tags, ", ")))
+	}
+
+	for _, port := range h.Ports {
+		if !portIsOpen(&port) {
+			continue
+		}
+
+		if annotation := v.annotations.portAnnotation(h, &port); annotation != nil && len(annotation.Tags) > 0 {
+			values = append(values, fmt.Sprintf("%s: %s", triagePortKey(&port), strings.Join(annotation.Tags, ", ")))
+		}
+	}
+	return values
+}
+
+// dnsNamesColumn shows the names found in DNS records for the host, along
+// with the tools that found them.
+func dnsNamesColumn(v *View, h *nmap.Host) []string {
+	var values []string
+	for _, dnsName := range v.dnsRecords.hostNames(h) {
+		values = append(values, fmt.Sprintf("%s (%s)", dnsName.Name, strings.Join(dnsName.Sources, ", ")))
+	}
+	return values
+}
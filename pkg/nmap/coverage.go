@@ -0,0 +1,319 @@
+package nmap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+type portRange struct {
+	start uint16
+	end   uint16
+}
+
+// PortRanges is the list of ports a scan probed, as described by the
+// services attribute of a scaninfo element (e.g. "1-1000,1433,3389").
+type PortRanges []portRange
+
+func parsePortRanges(services string) PortRanges {
+	var ranges PortRanges
+	for _, part := range strings.Split(services, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		startStr, endStr, isRange := strings.Cut(part, "-")
+		if !isRange {
+			endStr = startStr
+		}
+
+		start, err := strconv.ParseUint(startStr, 10, 16)
+		if err != nil {
+			continue
+		}
+
+		end, err := strconv.ParseUint(endStr, 10, 16)
+		if err != nil {
+			continue
+		}
+
+		ranges = append(ranges, portRange{start: uint16(start), end: uint16(end)})
+	}
+	return ranges
+}
+
+func (r PortRanges) Contains(port uint16) bool {
+	for _, pr := range r {
+		if port >= pr.start && port <= pr.end {
+			return true
+		}
+	}
+	return false
+}
+
+// Count returns the number of distinct ports in the ranges.
+func (r PortRanges) Count() int {
+	sorted := slices.Clone(r)
+	slices.SortFunc(sorted, func(a, b portRange) int {
+		return int(a.start) - int(b.start)
+	})
+
+	count := 0
+	next := 0 // first port not counted yet
+	for _, pr := range sorted {
+		start := max(int(pr.start), next)
+		if int(pr.end) >= start {
+			count += int(pr.end) - start + 1
+			next = int(pr.end) + 1
+		}
+	}
+	return count
+}
+
+// IsFullRange reports whether every port from 1 to 65535 was scanned.
+func (r PortRanges) IsFullRange() bool {
+	count := r.Count()
+	if r.Contains(0) {
+		count--
+	}
+	return count == 65535
+}
+
+// Coverage maps a protocol to the port ranges that were scanned.
+type Coverage map[string]PortRanges
+
+// Add adds the port ranges of other to the coverage.
+func (c Coverage) Add(other Coverage) {
+	for protocol, ranges := range other {
+		c[protocol] = append(c[protocol], ranges...)
+	}
+}
+
+func (c Coverage) Covers(protocol string, port uint16) bool {
+	ranges, ok := c[strings.ToLower(protocol)]
+	if !ok {
+		return false
+	}
+	return ranges.Contains(port)
+}
+
+// readScanCoverage collects every scaninfo element of a run. nmap.Run only
+// keeps a single ScanInfo, so runs mixing -sS and -sU need a separate pass.
+func readScanCoverage(data []byte) (Coverage, error) {
+	var scanInfos struct {
+		ScanInfo []nmap.ScanInfo `xml:"scaninfo"`
+	}
+
+	err := xml.Unmarshal(data, &scanInfos)
+	if err != nil {
+		return nil, err
+	}
+
+	coverage := Coverage{}
+	for _, info := range scanInfos.ScanInfo {
+		protocol := strings.ToLower(info.Protocol)
+		coverage[protocol] = append(coverage[protocol], parsePortRanges(info.Services)...)
+	}
+	return coverage, nil
+}
+
+// Scan is a single parsed nmap XML file.
+type Scan struct {
+	Path     string
+	Run      *nmap.Run
+	Coverage Coverage
+}
+
+// ReadScans parses each nmap XML file along with its scan coverage. Files
+// that can't be parsed are skipped.
+func ReadScans(paths []string) ([]*Scan, error) {
+	var scans []*Scan
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		scan, err := parseScan(path, data)
+		if err != nil {
+			log.Printf("[!] Skipping %s due to error: %s", path, err)
+			continue
+		}
+		scans = append(scans, scan)
+	}
+	return scans, nil
+}
+
+// ReadScan parses an nmap XML file along with its scan coverage. Unlike
+// ReadScans, it fails on files that can't be parsed.
+func ReadScan(path string) (*Scan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	scan, err := parseScan(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+	return scan, nil
+}
+
+// parseScan parses the nmap XML data of the file at path.
+func parseScan(path string, data []byte) (*Scan, error) {
+	run, err := nmap.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	coverage, err := readScanCoverage(data)
+	if err != nil {
+		log.Printf("[!] Unable to read scan coverage from %s: %s", path, err)
+	}
+
+	return &Scan{
+		Path:     path,
+		Run:      run,
+		Coverage: coverage,
+	}, nil
+}
+
+// HostCoverage returns the ports scanned on each host address across all
+// the scans the host was reported up in.
+func HostCoverage(scans []*Scan) map[string]Coverage {
+	coverage := map[string]Coverage{}
+	for _, scan := range scans {
+		for _, h := range scan.Run.Hosts {
+			if h.Status.State != "up" {
+				continue
+			}
+
+			for _, addr := range h.Addresses {
+				if _, ok := coverage[addr.Addr]; !ok {
+					coverage[addr.Addr] = Coverage{}
+				}
+				coverage[addr.Addr].Add(scan.Coverage)
+			}
+		}
+	}
+	return coverage
+}
+
+// nexScriptPrefix marks the pseudo scripts nex adds to ports. They are not
+// nmap output, so they are never searched and are left out of XML written
+// for other tools.
+const nexScriptPrefix = "nex-"
+
+// lastConfirmedScriptID is the pseudo script used to record when a port was
+// last reported by a scan. Storing it as a script keeps the timestamp in
+// merged XML, so merging a merged file again stays time-aware.
+const lastConfirmedScriptID = "nex-last-confirmed"
+
+// scanRecord is a single scan of a host: when it ran and which ports it
+// probed.
+type scanRecord struct {
+	seen     time.Time
+	coverage Coverage
+}
+
+// PortLastConfirmed returns when a merged scan last reported the port in its
+// current state. The zero time is returned if the port was never merged.
+func PortLastConfirmed(port nmap.Port) time.Time {
+	for _, script := range port.Scripts {
+		if script.ID != lastConfirmedScriptID {
+			continue
+		}
+
+		for _, elem := range script.Elements {
+			if elem.Key != "timestamp" {
+				continue
+			}
+
+			timestamp, err := strconv.ParseInt(elem.Value, 10, 64)
+			if err == nil {
+				return time.Unix(timestamp, 0)
+			}
+		}
+	}
+	return time.Time{}
+}
+
+func setPortLastConfirmed(port *nmap.Port, seen time.Time) {
+	script := nmap.Script{
+		ID:     lastConfirmedScriptID,
+		Output: seen.UTC().Format(time.RFC3339),
+		Elements: []nmap.Element{
+			{Key: "timestamp", Value: strconv.FormatInt(seen.Unix(), 10)},
+		},
+	}
+
+	for i := range port.Scripts {
+		if port.Scripts[i].ID == lastConfirmedScriptID {
+			port.Scripts[i] = script
+			return
+		}
+	}
+	port.Scripts = append(port.Scripts, script)
+}
+
+// withoutNexScripts returns a copy of the ports without the pseudo scripts
+// nex adds to them.
+func withoutNexScripts(ports []nmap.Port) []nmap.Port {
+	var stripped []nmap.Port
+	for _, port := range ports {
+		port.Scripts = slices.DeleteFunc(slices.Clone(port.Scripts), func(script nmap.Script) bool {
+			return strings.HasPrefix(script.ID, nexScriptPrefix)
+		})
+		stripped = append(stripped, port)
+	}
+	return stripped
+}
+
+// hostSeenAt returns when the host was scanned, falling back to the start of
+// the run for scans that don't record per host times.
+func hostSeenAt(run *nmap.Run, h nmap.Host) time.Time {
+	seen := time.Time(h.StartTime)
+	if seen.IsZero() || seen.Unix() == 0 {
+		seen = time.Time(run.Start)
+	}
+	return seen
+}
+
+// stampPorts records seen as the last confirmed time of every port that has
+// not been merged before. It reports whether any port was already stamped.
+func stampPorts(h *nmap.Host, seen time.Time) bool {
+	previouslyMerged := false
+	for i := range h.Ports {
+		if !PortLastConfirmed(h.Ports[i]).IsZero() {
+			previouslyMerged = true
+			continue
+		}
+		setPortLastConfirmed(&h.Ports[i], seen)
+	}
+	return previouslyMerged
+}
+
+// dropSupersededPorts removes ports that a later scan of the host covered but
+// did not report, meaning they were no longer in the state recorded earlier.
+func dropSupersededPorts(ports []nmap.Port, scans []scanRecord) []nmap.Port {
+	var current []nmap.Port
+	for _, port := range ports {
+		confirmed := PortLastConfirmed(port)
+		superseded := slices.ContainsFunc(scans, func(scan scanRecord) bool {
+			return scan.seen.After(confirmed) && scan.coverage.Covers(port.Protocol, port.ID)
+		})
+
+		if !superseded {
+			current = append(current, port)
+		}
+	}
+	return current
+}
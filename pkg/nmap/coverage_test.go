@@ -0,0 +1,53 @@
+package nmap
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParsePortRanges(t *testing.T) {
+	ranges := parsePortRanges("1-1000,1433, 3389,bad")
+	tests := []struct {
+		port uint16
+		want bool
+	}{
+		{port: 1, want: true},
+		{port: 1000, want: true},
+		{port: 1001, want: false},
+		{port: 1433, want: true},
+		{port: 3389, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.port), func(t *testing.T) {
+			if got := ranges.Contains(tt.port); got != tt.want {
+				t.Errorf("Contains(%d) = %v, want %v", tt.port, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPortRangesCount(t *testing.T) {
+	tests := []struct {
+		services string
+		want     int
+		wantFull bool
+	}{
+		{services: "1-1000", want: 1000},
+		{services: "1-100,50-150,150", want: 150},
+		{services: "1-65535", want: 65535, wantFull: true},
+		{services: "0-65535", want: 65536, wantFull: true},
+		{services: "1-30000,30001-65535", want: 65535, wantFull: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.services, func(t *testing.T) {
+			ranges := parsePortRanges(tt.services)
+			if got := ranges.Count(); got != tt.want {
+				t.Errorf("Count() = %v, want %v", got, tt.want)
+			}
+
+			if got := ranges.IsFullRange(); got != tt.wantFull {
+				t.Errorf("IsFullRange() = %v, want %v", got, tt.wantFull)
+			}
+		})
+	}
+}
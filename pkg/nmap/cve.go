@@ -0,0 +1,378 @@
+package nmap
+
+import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+// cpeName is the part of a CPE used to match services to CVEs.
+type cpeName struct {
+	part    string
+	vendor  string
+	product string
+	version string
+}
+
+func (c cpeName) key() string {
+	return fmt.Sprintf("%s:%s:%s", c.part, c.vendor, c.product)
+}
+
+// parseCPE parses CPE 2.2 URIs ("cpe:/a:openbsd:openssh:7.4p1"), as reported
+// by nmap, and CPE 2.3 names ("cpe:2.3:a:openbsd:openssh:7.4:p1:..."), as
+// used by NVD. Any version ("*") and no version ("-") are returned as an
+// empty version. The update is joined to the version, the way nmap reports
+// it.
+func parseCPE(cpe string) (cpeName, bool) {
+	var fields []string
+	if rest, ok := strings.CutPrefix(cpe, "cpe:2.3:"); ok {
+		fields = strings.Split(rest, ":")
+	} else if rest, ok := strings.CutPrefix(cpe, "cpe:/"); ok {
+		fields = strings.Split(rest, ":")
+	} else {
+		return cpeName{}, false
+	}
+
+	if len(fields) < 3 {
+		return cpeName{}, false
+	}
+
+	name := cpeName{
+		part:    fields[0],
+		vendor:  strings.ToLower(fields[1]),
+		product: strings.ToLower(fields[2]),
+	}
+
+	if len(fields) > 3 && fields[3] != "*" && fields[3] != "-" {
+		name.version = strings.ReplaceAll(fields[3], `\`, "")
+
+		if len(fields) > 4 && fields[4] != "*" && fields[4] != "-" {
+			name.version += strings.ReplaceAll(fields[4], `\`, "")
+		}
+	}
+	return name, true
+}
+
+// CVERange is a range of versions of a product affected by a CVE. A range
+// without a version or bounds affects every version.
+type CVERange struct {
+	ID             string  `json:"id"`
+	CVSS           float64 `json:"cvss"`
+	Version        string  `json:"version,omitempty"`
+	StartIncluding string  `json:"start_including,omitempty"`
+	StartExcluding string  `json:"start_excluding,omitempty"`
+	EndIncluding   string  `json:"end_including,omitempty"`
+	EndExcluding   string  `json:"end_excluding,omitempty"`
+}
+
+func (r CVERange) matches(version string) bool {
+	if r.Version != "" {
+		return CompareVersions(version, r.Version) == 0
+	}
+
+	if r.StartIncluding != "" && CompareVersions(version, r.StartIncluding) < 0 {
+		return false
+	}
+
+	if r.StartExcluding != "" && CompareVersions(version, r.StartExcluding) <= 0 {
+		return false
+	}
+
+	if r.EndIncluding != "" && CompareVersions(version, r.EndIncluding) > 0 {
+		return false
+	}
+
+	if r.EndExcluding != "" && CompareVersions(version, r.EndExcluding) >= 0 {
+		return false
+	}
+	return true
+}
+
+// CVEMatch is a CVE matched to a service by its CPE and version.
+type CVEMatch struct {
+	ID      string  `json:"id"`
+	CVSS    float64 `json:"cvss"`
+	CPE     string  `json:"cpe"`
+	Version string  `json:"version"`
+}
+
+// CVEIndex maps products ("a:vendor:product") to the CVE ranges affecting
+// them. It is built from NVD JSON feeds and can be saved as a compact index
+// with Write.
+type CVEIndex struct {
+	Products map[string][]CVERange `json:"products"`
+
+	added map[productRange]bool
+}
+
+type productRange struct {
+	product string
+	r       CVERange
+}
+
+func NewCVEIndex() *CVEIndex {
+	return &CVEIndex{
+		Products: map[string][]CVERange{},
+		added:    map[productRange]bool{},
+	}
+}
+
+// nvdCPEMatch is a CPE match of both the 1.1 feeds and the 2.0 API.
+type nvdCPEMatch struct {
+	Vulnerable            bool   `json:"vulnerable"`
+	CPE23URI              string `json:"cpe23Uri"`
+	Criteria              string `json:"criteria"`
+	VersionStartIncluding string `json:"versionStartIncluding"`
+	VersionStartExcluding string `json:"versionStartExcluding"`
+	VersionEndIncluding   string `json:"versionEndIncluding"`
+	VersionEndExcluding   string `json:"versionEndExcluding"`
+}
+
+type nvdNode struct {
+	Children []nvdNode     `json:"children"`
+	Matches  []nvdCPEMatch `json:"cpe_match"`
+	Matches2 []nvdCPEMatch `json:"cpeMatch"`
+}
+
+type nvdScore struct {
+	CVSSData struct {
+		BaseScore float64 `json:"baseScore"`
+	} `json:"cvssData"`
+}
+
+// cveDocument is any of the files LoadCVEIndex reads: a compact index, an
+// NVD 1.1 JSON feed or an NVD 2.0 API response.
+type cveDocument struct {
+	Products map[string][]CVERange `json:"products"`
+
+	CVEItems []struct {
+		CVE struct {
+			Meta struct {
+				ID string `json:"ID"`
+			} `json:"CVE_data_meta"`
+		} `json:"cve"`
+		Configurations struct {
+			Nodes []nvdNode `json:"nodes"`
+		} `json:"configurations"`
+		Impact struct {
+			BaseMetricV3 struct {
+				CVSSV3 struct {
+					BaseScore float64 `json:"baseScore"`
+				} `json:"cvssV3"`
+			} `json:"baseMetricV3"`
+			BaseMetricV2 struct {
+				CVSSV2 struct {
+					BaseScore float64 `json:"baseScore"`
+				} `json:"cvssV2"`
+			} `json:"baseMetricV2"`
+		} `json:"impact"`
+	} `json:"CVE_Items"`
+
+	Vulnerabilities []struct {
+		CVE struct {
+			ID      string `json:"id"`
+			Metrics struct {
+				CVSSMetricV31 []nvdScore `json:"cvssMetricV31"`
+				CVSSMetricV30 []nvdScore `json:"cvssMetricV30"`
+				CVSSMetricV2  []nvdScore `json:"cvssMetricV2"`
+			} `json:"metrics"`
+			Configurations []struct {
+				Nodes []nvdNode `json:"nodes"`
+			} `json:"configurations"`
+		} `json:"cve"`
+	} `json:"vulnerabilities"`
+}
+
+// LoadCVEIndex loads compact indexes and NVD JSON feeds, optionally
+// gzipped, into a single index.
+func LoadCVEIndex(paths ...string) (*CVEIndex, error) {
+	index := NewCVEIndex()
+	for _, path := range paths {
+		err := index.load(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load %s: %w", path, err)
+		}
+	}
+	return index, nil
+}
+
+func (idx *CVEIndex) load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+
+		data, err = io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+	}
+
+	var doc cveDocument
+	err = json.Unmarshal(data, &doc)
+	if err != nil {
+		return err
+	}
+
+	if doc.Products == nil && doc.CVEItems == nil && doc.Vulnerabilities == nil {
+		return fmt.Errorf("not a CVE index or NVD JSON feed")
+	}
+
+	for product, ranges := range doc.Products {
+		for _, r := range ranges {
+			idx.add(product, r)
+		}
+	}
+
+	for _, item := range doc.CVEItems {
+		score := cmp.Or(item.Impact.BaseMetricV3.CVSSV3.BaseScore, item.Impact.BaseMetricV2.CVSSV2.BaseScore)
+		for _, node := range item.Configurations.Nodes {
+			idx.addNode(item.CVE.Meta.ID, score, node)
+		}
+	}
+
+	for _, vuln := range doc.Vulnerabilities {
+		metrics := vuln.CVE.Metrics
+		score := 0.0
+		for _, scores := range [][]nvdScore{metrics.CVSSMetricV31, metrics.CVSSMetricV30, metrics.CVSSMetricV2} {
+			if len(scores) > 0 {
+				score = scores[0].CVSSData.BaseScore
+				break
+			}
+		}
+
+		for _, config := range vuln.CVE.Configurations {
+			for _, node := range config.Nodes {
+				idx.addNode(vuln.CVE.ID, score, node)
+			}
+		}
+	}
+	return nil
+}
+
+// addNode adds the vulnerable CPEs of a configuration node. Platform CPEs
+// the vulnerable ones have to run on are not vulnerable themselves, so they
+// are skipped.
+func (idx *CVEIndex) addNode(id string, score float64, node nvdNode) {
+	for _, match := range append(node.Matches, node.Matches2...) {
+		if !match.Vulnerable {
+			continue
+		}
+
+		name, ok := parseCPE(cmp.Or(match.Criteria, match.CPE23URI))
+		if !ok {
+			continue
+		}
+
+		r := CVERange{
+			ID:             id,
+			CVSS:           score,
+			Version:        name.version,
+			StartIncluding: match.VersionStartIncluding,
+			StartExcluding: match.VersionStartExcluding,
+			EndIncluding:   match.VersionEndIncluding,
+			EndExcluding:   match.VersionEndExcluding,
+		}
+
+		idx.add(name.key(), r)
+	}
+
+	for _, child := range node.Children {
+		idx.addNode(id, score, child)
+	}
+}
+
+func (idx *CVEIndex) add(product string, r CVERange) {
+	key := productRange{product: product, r: r}
+	if !idx.added[key] {
+		idx.added[key] = true
+		idx.Products[product] = append(idx.Products[product], r)
+	}
+}
+
+// Write saves the index as JSON, gzipped if the path ends in .gz.
+func (idx *CVEIndex) Write(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = f
+	var gz *gzip.Writer
+	if strings.HasSuffix(path, ".gz") {
+		gz = gzip.NewWriter(f)
+		w = gz
+	}
+
+	err = json.NewEncoder(w).Encode(idx)
+	if err == nil && gz != nil {
+		err = gz.Close()
+	}
+
+	// a failed close can leave a truncated index behind
+	return errors.Join(err, f.Close())
+}
+
+// CVECount returns the number of distinct CVEs in the index.
+func (idx *CVEIndex) CVECount() int {
+	ids := map[string]bool{}
+	for _, ranges := range idx.Products {
+		for _, r := range ranges {
+			ids[r.ID] = true
+		}
+	}
+	return len(ids)
+}
+
+// Match returns the CVEs affecting the service, highest score first.
+// Services without a known version are never matched, since every CVE of
+// the product would match them.
+func (idx *CVEIndex) Match(service nmap.Service) []CVEMatch {
+	var matches []CVEMatch
+	seen := map[string]bool{}
+	for _, cpe := range service.CPEs {
+		name, ok := parseCPE(string(cpe))
+		if !ok {
+			continue
+		}
+
+		version := cmp.Or(name.version, service.Version)
+		if version == "" {
+			continue
+		}
+
+		for _, r := range idx.Products[name.key()] {
+			if seen[r.ID] || !r.matches(version) {
+				continue
+			}
+
+			seen[r.ID] = true
+			matches = append(matches, CVEMatch{
+				ID:      r.ID,
+				CVSS:    r.CVSS,
+				CPE:     string(cpe),
+				Version: version,
+			})
+		}
+	}
+
+	slices.SortFunc(matches, func(a, b CVEMatch) int {
+		return cmp.Or(cmp.Compare(b.CVSS, a.CVSS), strings.Compare(a.ID, b.ID))
+	})
+	return matches
+}
@@ -0,0 +1,113 @@
+package nmap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+const nvdFeed11 = `{"CVE_Items": [{
+  "cve": {"CVE_data_meta": {"ID": "CVE-2018-15473"}},
+  "impact": {"baseMetricV3": {"cvssV3": {"baseScore": 5.3}}},
+  "configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+    {"vulnerable": true, "cpe23Uri": "cpe:2.3:a:openbsd:openssh:*:*:*:*:*:*:*:*", "versionEndIncluding": "7.7"}
+  ]}]}
+}]}`
+
+const nvdFeed20 = `{"vulnerabilities": [{"cve": {
+  "id": "CVE-2023-38408",
+  "metrics": {"cvssMetricV31": [{"cvssData": {"baseScore": 9.8}}]},
+  "configurations": [{"nodes": [{"operator": "OR", "cpeMatch": [
+    {"vulnerable": true, "criteria": "cpe:2.3:a:openbsd:openssh:*:*:*:*:*:*:*:*", "versionEndExcluding": "9.3"},
+    {"vulnerable": false, "criteria": "cpe:2.3:o:linux:linux_kernel:-:*:*:*:*:*:*:*"}
+  ]}]}]
+}}, {"cve": {
+  "id": "CVE-2021-41617",
+  "metrics": {"cvssMetricV31": [{"cvssData": {"baseScore": 7.0}}]},
+  "configurations": [{"nodes": [{"operator": "OR", "cpeMatch": [
+    {"vulnerable": true, "criteria": "cpe:2.3:a:openbsd:openssh:*:*:*:*:*:*:*:*", "versionStartIncluding": "6.2", "versionEndExcluding": "8.8"}
+  ]}]}]
+}}, {"cve": {
+  "id": "CVE-2016-10012",
+  "metrics": {"cvssMetricV31": [{"cvssData": {"baseScore": 7.8}}]},
+  "configurations": [{"nodes": [{"operator": "OR", "cpeMatch": [
+    {"vulnerable": true, "criteria": "cpe:2.3:a:openbsd:openssh:7.4:p1:*:*:*:*:*:*"}
+  ]}]}]
+}}]}`
+
+func TestCVEIndex(t *testing.T) {
+	dir := t.TempDir()
+	feed11 := filepath.Join(dir, "nvdcve-1.1-2018.json")
+	feed20 := filepath.Join(dir, "nvdcve-2.0.json")
+	for path, data := range map[string]string{feed11: nvdFeed11, feed20: nvdFeed20} {
+		err := os.WriteFile(path, []byte(data), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	built, err := LoadCVEIndex(feed11, feed20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	indexPath := filepath.Join(dir, "index.json.gz")
+	err = built.Write(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a saved index should match the same CVEs as the feeds it was built from
+	saved, err := LoadCVEIndex(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		service nmap.Service
+		want    []string
+	}{
+		{
+			name:    "version from the CPE",
+			service: nmap.Service{Product: "OpenSSH", CPEs: []nmap.CPE{"cpe:/a:openbsd:openssh:7.4"}},
+			want:    []string{"CVE-2023-38408 9.8", "CVE-2021-41617 7.0", "CVE-2018-15473 5.3"},
+		},
+		{
+			name:    "version with an update",
+			service: nmap.Service{Product: "OpenSSH", CPEs: []nmap.CPE{"cpe:/a:openbsd:openssh:7.4p1"}},
+			want:    []string{"CVE-2023-38408 9.8", "CVE-2016-10012 7.8", "CVE-2021-41617 7.0", "CVE-2018-15473 5.3"},
+		},
+		{
+			name:    "version from the service",
+			service: nmap.Service{Product: "OpenSSH", Version: "8.9p1", CPEs: []nmap.CPE{"cpe:/a:openbsd:openssh"}},
+			want:    []string{"CVE-2023-38408 9.8"},
+		},
+		{
+			name:    "unknown version",
+			service: nmap.Service{Product: "OpenSSH", CPEs: []nmap.CPE{"cpe:/a:openbsd:openssh"}},
+		},
+		{
+			name:    "not vulnerable platform",
+			service: nmap.Service{CPEs: []nmap.CPE{"cpe:/o:linux:linux_kernel:5.4"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, index := range []*CVEIndex{built, saved} {
+				var got []string
+				for _, match := range index.Match(tt.service) {
+					got = append(got, fmt.Sprintf("%s %.1f", match.ID, match.CVSS))
+				}
+
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("Match() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
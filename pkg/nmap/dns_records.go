@@ -0,0 +1,346 @@
+package nmap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+// DNS record types mapped to scanned hosts.
+const (
+	DNSRecordA     = "A"
+	DNSRecordAAAA  = "AAAA"
+	DNSRecordCNAME = "CNAME"
+)
+
+// Subdomain enumeration tools DNS records are imported from.
+const (
+	DNSSourceAmass     = "amass"
+	DNSSourceSubfinder = "subfinder"
+	DNSSourceDNSX      = "dnsx"
+	DNSSourceMassDNS   = "massdns"
+)
+
+// maxCNAMEDepth bounds how many CNAMEs are followed to reach an address.
+const maxCNAMEDepth = 10
+
+// DNSRecord is an A, AAAA or CNAME record found by a subdomain enumeration
+// tool.
+type DNSRecord struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// dnsRecordLine holds the fields of the JSON lines written by amass (-json),
+// subfinder (-oJ), dnsx (-json) and massdns (-o J).
+type dnsRecordLine struct {
+	Name      string `json:"name"`
+	Addresses []struct {
+		IP string `json:"ip"`
+	} `json:"addresses"`
+
+	Host   string   `json:"host"`
+	Source string   `json:"source"`
+	IP     string   `json:"ip"`
+	A      []string `json:"a"`
+	AAAA   []string `json:"aaaa"`
+	CNAME  []string `json:"cname"`
+
+	Data struct {
+		Answers []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+			Data string `json:"data"`
+		} `json:"answers"`
+	} `json:"data"`
+}
+
+// addressRecord returns an A or AAAA record of the name, depending on the IP
+// version. It returns false if value is not an IP.
+func addressRecord(name string, value string, source string) (DNSRecord, bool) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(value))
+	if err != nil {
+		return DNSRecord{}, false
+	}
+
+	recordType := DNSRecordA
+	if addr.Unmap().Is6() {
+		recordType = DNSRecordAAAA
+	}
+	return DNSRecord{Name: name, Type: recordType, Value: addr.Unmap().String(), Source: source}, true
+}
+
+// parseDNSRecordJSON parses a JSON line, telling the tools apart by their
+// fields.
+func parseDNSRecordJSON(line string) ([]DNSRecord, error) {
+	var parsed dnsRecordLine
+	err := json.Unmarshal([]byte(line), &parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []DNSRecord
+	addAddresses := func(name string, values []string, source string) {
+		for _, value := range values {
+			if record, ok := addressRecord(name, value, source); ok {
+				records = append(records, record)
+			}
+		}
+	}
+
+	switch {
+	case len(parsed.Data.Answers) > 0:
+		for _, answer := range parsed.Data.Answers {
+			switch strings.ToUpper(answer.Type) {
+			case DNSRecordA, DNSRecordAAAA:
+				addAddresses(answer.Name, []string{answer.Data}, DNSSourceMassDNS)
+			case DNSRecordCNAME:
+				records = append(records, DNSRecord{Name: answer.Name, Type: DNSRecordCNAME, Value: answer.Data, Source: DNSSourceMassDNS})
+			}
+		}
+	case parsed.Addresses != nil:
+		for _, address := range parsed.Addresses {
+			addAddresses(parsed.Name, []string{address.IP}, DNSSourceAmass)
+		}
+	case parsed.Host != "" && parsed.Source != "":
+		if parsed.IP != "" {
+			addAddresses(parsed.Host, []string{parsed.IP}, DNSSourceSubfinder)
+		}
+	case parsed.Host != "":
+		addAddresses(parsed.Host, parsed.A, DNSSourceDNSX)
+		addAddresses(parsed.Host, parsed.AAAA, DNSSourceDNSX)
+		for _, cname := range parsed.CNAME {
+			records = append(records, DNSRecord{Name: parsed.Host, Type: DNSRecordCNAME, Value: cname, Source: DNSSourceDNSX})
+		}
+	case parsed.Name == "" && parsed.Host == "":
+		return nil, fmt.Errorf("unknown JSON record format")
+	}
+	return records, nil
+}
+
+// parseDNSRecordText parses a line of text output: amass's
+// "www.example.com (FQDN) --> a_record --> 1.2.3.4 (IPAddress)", massdns's
+// simple "www.example.com. A 1.2.3.4" and full "www.example.com. 300 IN A
+// 1.2.3.4" answers, or subfinder's "www.example.com,1.2.3.4". Lines without
+// an A, AAAA or CNAME record, like plain hostnames, are skipped.
+func parseDNSRecordText(line string) []DNSRecord {
+	if parts := strings.Split(line, "-->"); len(parts) == 3 {
+		name := strings.Fields(parts[0])
+		value := strings.Fields(parts[2])
+		if len(name) == 0 || len(value) == 0 {
+			return nil
+		}
+
+		switch strings.TrimSpace(parts[1]) {
+		case "a_record", "aaaa_record":
+			if record, ok := addressRecord(name[0], value[0], DNSSourceAmass); ok {
+				return []DNSRecord{record}
+			}
+		case "cname_record":
+			return []DNSRecord{{Name: name[0], Type: DNSRecordCNAME, Value: value[0], Source: DNSSourceAmass}}
+		}
+		return nil
+	}
+
+	if name, value, ok := strings.Cut(line, ","); ok {
+		value, _, _ = strings.Cut(value, ",")
+		if record, ok := addressRecord(strings.TrimSpace(name), value, DNSSourceSubfinder); ok {
+			return []DNSRecord{record}
+		}
+		return nil
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 5 && strings.EqualFold(fields[2], "IN") {
+		fields = []string{fields[0], fields[3], fields[4]}
+	}
+
+	if len(fields) != 3 {
+		return nil
+	}
+
+	switch strings.ToUpper(fields[1]) {
+	case DNSRecordA, DNSRecordAAAA:
+		if record, ok := addressRecord(fields[0], fields[2], DNSSourceMassDNS); ok {
+			return []DNSRecord{record}
+		}
+	case DNSRecordCNAME:
+		return []DNSRecord{{Name: fields[0], Type: DNSRecordCNAME, Value: fields[2], Source: DNSSourceMassDNS}}
+	}
+	return nil
+}
+
+// ParseDNSRecords parses the output of amass, subfinder, dnsx or massdns,
+// detecting the tool from each line.
+func ParseDNSRecords(r io.Reader) ([]DNSRecord, error) {
+	var records []DNSRecord
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		var lineRecords []DNSRecord
+		if strings.HasPrefix(line, "{") {
+			var err error
+			lineRecords, err = parseDNSRecordJSON(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+		} else {
+			lineRecords = parseDNSRecordText(line)
+		}
+
+		for _, record := range lineRecords {
+			record.Name = normalizeHostname(record.Name)
+			if record.Type == DNSRecordCNAME {
+				record.Value = normalizeHostname(record.Value)
+			}
+
+			if record.Name != "" && record.Value != "" {
+				records = append(records, record)
+			}
+		}
+	}
+	return records, scanner.Err()
+}
+
+// DNSName is a name resolving to a host found in DNS records, along with the
+// tools that found it.
+type DNSName struct {
+	Name    string   `json:"name"`
+	Sources []string `json:"sources"`
+}
+
+// DNSRecords maps addresses to the names resolving to them, directly or
+// through CNAMEs, and the sources of those names.
+type DNSRecords struct {
+	Records []DNSRecord
+
+	names map[string]map[string][]string
+}
+
+// LoadDNSRecords reads DNS records from the output files of subdomain
+// enumeration tools.
+func LoadDNSRecords(paths ...string) (*DNSRecords, error) {
+	var records []DNSRecord
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		fileRecords, err := ParseDNSRecords(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse DNS records %s: %w", path, err)
+		}
+		records = append(records, fileRecords...)
+	}
+	return NewDNSRecords(records), nil
+}
+
+// NewDNSRecords resolves the records, following CNAMEs, to the addresses
+// each name points to.
+func NewDNSRecords(records []DNSRecord) *DNSRecords {
+	addrs := map[string][]string{}
+	cnames := map[string][]string{}
+	sources := map[string][]string{}
+	for _, record := range records {
+		if record.Type == DNSRecordCNAME {
+			cnames[record.Name] = append(cnames[record.Name], record.Value)
+		} else {
+			addrs[record.Name] = append(addrs[record.Name], record.Value)
+		}
+
+		if !slices.Contains(sources[record.Name], record.Source) {
+			sources[record.Name] = append(sources[record.Name], record.Source)
+		}
+	}
+
+	var resolve func(name string, depth int, seen map[string]bool) []string
+	resolve = func(name string, depth int, seen map[string]bool) []string {
+		if seen[name] || depth > maxCNAMEDepth {
+			return nil
+		}
+		seen[name] = true
+
+		resolved := slices.Clone(addrs[name])
+		for _, target := range cnames[name] {
+			resolved = append(resolved, resolve(target, depth+1, seen)...)
+		}
+		return resolved
+	}
+
+	d := &DNSRecords{Records: records, names: map[string]map[string][]string{}}
+	for name, nameSources := range sources {
+		for _, addr := range resolve(name, 0, map[string]bool{}) {
+			if d.names[addr] == nil {
+				d.names[addr] = map[string][]string{}
+			}
+
+			for _, source := range nameSources {
+				if !slices.Contains(d.names[addr][name], source) {
+					d.names[addr][name] = append(d.names[addr][name], source)
+				}
+			}
+		}
+	}
+	return d
+}
+
+// hostNames returns the names resolving to the addresses of the host, sorted by
+// name. Names nmap already has for the host are left out. The host itself is
+// left alone, so the names never end up in XML written for other tools.
+func (d *DNSRecords) hostNames(h *nmap.Host) []DNSName {
+	if d == nil {
+		return nil
+	}
+
+	names := map[string][]string{}
+	for _, addr := range h.Addresses {
+		parsed, err := netip.ParseAddr(addr.Addr)
+		if err != nil {
+			continue
+		}
+
+		for name, sources := range d.names[parsed.Unmap().String()] {
+			for _, source := range sources {
+				if !slices.Contains(names[name], source) {
+					names[name] = append(names[name], source)
+				}
+			}
+		}
+	}
+
+	for _, hostname := range h.Hostnames {
+		delete(names, normalizeHostname(hostname.Name))
+	}
+
+	var dnsNames []DNSName
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		slices.Sort(names[name])
+		dnsNames = append(dnsNames, DNSName{Name: name, Sources: names[name]})
+	}
+	return dnsNames
+}
+
+// SetDNSRecords sets the DNS records used to find more names for hosts. The
+// names are matched by the host filters and used to build URLs, but are not
+// added to the hostnames of hosts.
+func (v *View) SetDNSRecords(records *DNSRecords) {
+	v.dnsRecords = records
+	v.hosts = nil
+}
@@ -0,0 +1,203 @@
+package nmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+func TestParseDNSRecords(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []DNSRecord
+	}{
+		{
+			name:  "amass json",
+			input: `{"name":"WWW.example.com","domain":"example.com","addresses":[{"ip":"10.0.0.1","cidr":"10.0.0.0/24","asn":0},{"ip":"2001:db8::1"}],"sources":["crtsh"]}`,
+			want: []DNSRecord{
+				{Name: "www.example.com", Type: DNSRecordA, Value: "10.0.0.1", Source: DNSSourceAmass},
+				{Name: "www.example.com", Type: DNSRecordAAAA, Value: "2001:db8::1", Source: DNSSourceAmass},
+			},
+		},
+		{
+			name:  "amass text",
+			input: "www.example.com (FQDN) --> cname_record --> web.example.net (FQDN)\nweb.example.net (FQDN) --> a_record --> 10.0.0.1 (IPAddress)\nexample.com (FQDN) --> ns_record --> ns1.example.com (FQDN)",
+			want: []DNSRecord{
+				{Name: "www.example.com", Type: DNSRecordCNAME, Value: "web.example.net", Source: DNSSourceAmass},
+				{Name: "web.example.net", Type: DNSRecordA, Value: "10.0.0.1", Source: DNSSourceAmass},
+			},
+		},
+		{
+			name:  "subfinder",
+			input: "{\"host\":\"api.example.com\",\"input\":\"example.com\",\"source\":\"crtsh\",\"ip\":\"10.0.0.2\"}\n{\"host\":\"dev.example.com\",\"input\":\"example.com\",\"source\":\"crtsh\"}\nmail.example.com,10.0.0.3\nplain.example.com",
+			want: []DNSRecord{
+				{Name: "api.example.com", Type: DNSRecordA, Value: "10.0.0.2", Source: DNSSourceSubfinder},
+				{Name: "mail.example.com", Type: DNSRecordA, Value: "10.0.0.3", Source: DNSSourceSubfinder},
+			},
+		},
+		{
+			name:  "dnsx",
+			input: `{"host":"cdn.example.com","resolver":["1.1.1.1:53"],"a":["10.0.0.4"],"cname":["cdn.example.net"],"status_code":"NOERROR"}`,
+			want: []DNSRecord{
+				{Name: "cdn.example.com", Type: DNSRecordA, Value: "10.0.0.4", Source: DNSSourceDNSX},
+				{Name: "cdn.example.com", Type: DNSRecordCNAME, Value: "cdn.example.net", Source: DNSSourceDNSX},
+			},
+		},
+		{
+			name:  "massdns",
+			input: "; comment\nvpn.example.com. A 10.0.0.5\nold.example.com. CNAME vpn.example.com.\nexample.com. NS ns1.example.com.\nv6.example.com. 300 IN AAAA 2001:db8::5\n" + `{"name":"j.example.com.","type":"A","class":"IN","status":"NOERROR","data":{"answers":[{"ttl":300,"type":"CNAME","class":"IN","name":"j.example.com.","data":"vpn.example.com."},{"ttl":300,"type":"A","class":"IN","name":"vpn.example.com.","data":"10.0.0.5"}]}}`,
+			want: []DNSRecord{
+				{Name: "vpn.example.com", Type: DNSRecordA, Value: "10.0.0.5", Source: DNSSourceMassDNS},
+				{Name: "old.example.com", Type: DNSRecordCNAME, Value: "vpn.example.com", Source: DNSSourceMassDNS},
+				{Name: "v6.example.com", Type: DNSRecordAAAA, Value: "2001:db8::5", Source: DNSSourceMassDNS},
+				{Name: "j.example.com", Type: DNSRecordCNAME, Value: "vpn.example.com", Source: DNSSourceMassDNS},
+				{Name: "vpn.example.com", Type: DNSRecordA, Value: "10.0.0.5", Source: DNSSourceMassDNS},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDNSRecords(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("ParseDNSRecords() error = %v", err)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseDNSRecords() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDNSRecordsInvalid(t *testing.T) {
+	_, err := ParseDNSRecords(strings.NewReader("a.example.com A 10.0.0.1\n{\"name\":"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("ParseDNSRecords() error = %v, want an error on line 2", err)
+	}
+}
+
+func TestDNSRecordsNames(t *testing.T) {
+	records := NewDNSRecords([]DNSRecord{
+		{Name: "www.example.com", Type: DNSRecordCNAME, Value: "lb.example.net", Source: DNSSourceAmass},
+		{Name: "lb.example.net", Type: DNSRecordA, Value: "10.0.0.1", Source: DNSSourceDNSX},
+		{Name: "api.example.com", Type: DNSRecordA, Value: "10.0.0.1", Source: DNSSourceSubfinder},
+		{Name: "api.example.com", Type: DNSRecordA, Value: "10.0.0.1", Source: DNSSourceDNSX},
+		{Name: "web.example.com", Type: DNSRecordA, Value: "10.0.0.1", Source: DNSSourceDNSX},
+		{Name: "loop.example.com", Type: DNSRecordCNAME, Value: "loop.example.com", Source: DNSSourceAmass},
+		{Name: "other.example.com", Type: DNSRecordA, Value: "10.0.0.2", Source: DNSSourceDNSX},
+	})
+
+	h := &nmap.Host{
+		Addresses: []nmap.Address{{Addr: "10.0.0.1", AddrType: "ipv4"}},
+		Hostnames: []nmap.Hostname{{Name: "web.example.com", Type: "PTR"}},
+	}
+
+	var got []string
+	for _, name := range records.hostNames(h) {
+		got = append(got, name.Name+" "+strings.Join(name.Sources, ","))
+	}
+
+	want := []string{
+		"api.example.com dnsx,subfinder",
+		"lb.example.net dnsx",
+		"www.example.com amass",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("hostNames() = %v, want %v", got, want)
+	}
+
+	var none *DNSRecords
+	if got := none.hostNames(h); got != nil {
+		t.Errorf("nil hostNames() = %v, want nil", got)
+	}
+}
+
+func TestViewDNSRecords(t *testing.T) {
+	run := &nmap.Run{Hosts: []nmap.Host{{
+		Status:    nmap.Status{State: "up"},
+		Addresses: []nmap.Address{{Addr: "10.0.0.1", AddrType: "ipv4"}},
+		Hostnames: []nmap.Hostname{{Name: "web.example.com", Type: "PTR"}},
+		Ports: []nmap.Port{{
+			ID:       443,
+			Protocol: "tcp",
+			State:    nmap.State{State: "open"},
+			Service:  nmap.Service{Name: "http", Tunnel: "ssl"},
+		}},
+	}}}
+
+	view := NewNmapView(run)
+	view.SetDNSRecords(NewDNSRecords([]DNSRecord{
+		{Name: "app.example.com", Type: DNSRecordA, Value: "10.0.0.1", Source: DNSSourceSubfinder},
+	}))
+
+	var got []string
+	for _, record := range view.GetURLRecords("", 0) {
+		got = append(got, record.URL+" "+strings.Join(record.Evidence, ","))
+	}
+
+	want := []string{
+		"https://10.0.0.1 address",
+		"https://app.example.com dns-record:subfinder",
+		"https://web.example.com hostname:PTR",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("GetURLRecords() = %v, want %v", got, want)
+	}
+
+	xmlRun, err := view.GetRun(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, h := range append(view.GetHosts(), &xmlRun.Hosts[0]) {
+		if len(h.Hostnames) != 1 {
+			t.Errorf("hostnames = %v, want only the PTR hostname", h.Hostnames)
+		}
+	}
+
+	var buf bytes.Buffer
+	view.SetOutput(&buf)
+	err = view.PrintJSON(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hosts []struct {
+		Hostnames []nmap.Hostname `json:"hostnames"`
+		DNSNames  []DNSName       `json:"dns_names"`
+	}
+	err = json.Unmarshal(buf.Bytes(), &hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantNames := []DNSName{{Name: "app.example.com", Sources: []string{DNSSourceSubfinder}}}
+	if len(hosts) != 1 || len(hosts[0].Hostnames) != 1 || !slices.EqualFunc(hosts[0].DNSNames, wantNames, func(a, b DNSName) bool {
+		return a.Name == b.Name && slices.Equal(a.Sources, b.Sources)
+	}) {
+		t.Errorf("PrintJSON() hosts = %+v, want dns_names %v apart from the PTR hostname", hosts, wantNames)
+	}
+
+	err = view.SetColumns([]string{"dns_names"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	headers, rows := view.tableRows("IP", 0)
+	if headers[len(headers)-1] != "DNS Names" || len(rows) != 1 || rows[0][len(rows[0])-1] != "app.example.com (subfinder)" {
+		t.Errorf("tableRows() = %q, %q", headers, rows)
+	}
+
+	filtered := NewNmapView(run)
+	filtered.SetDNSRecords(view.dnsRecords)
+	filtered.SetFilter(HostListFilter([]string{"app.example.com"}, nil))
+	if got := len(filtered.GetHosts()); got != 1 {
+		t.Errorf("GetHosts() with a DNS name filter = %d hosts, want 1", got)
+	}
+}
@@ -0,0 +1,141 @@
+package nmap
+
+import (
+	"cmp"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/Ullaakut/nmap/v2"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Host attribute keys of GeoIP fields.
+const (
+	GeoCountry     = "country"
+	GeoCountryName = "country_name"
+	GeoCity        = "city"
+	GeoASN         = "asn"
+	GeoASOrg       = "as_org"
+)
+
+var geoKeys = []string{GeoCountry, GeoCountryName, GeoCity, GeoASN, GeoASOrg}
+
+// geoRecord holds the fields nex uses from the GeoLite2/GeoIP2 City, Country
+// and ASN databases. Each database only fills in its own fields.
+type geoRecord struct {
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	AutonomousSystemNumber       uint   `maxminddb:"autonomous_system_number"`
+	AutonomousSystemOrganization string `maxminddb:"autonomous_system_organization"`
+}
+
+// GeoInfo is the location and network owner of an IP address.
+type GeoInfo struct {
+	Country     string `json:"country,omitempty"`
+	CountryName string `json:"country_name,omitempty"`
+	City        string `json:"city,omitempty"`
+	ASN         uint   `json:"asn,omitempty"`
+	ASOrg       string `json:"as_org,omitempty"`
+}
+
+func (g GeoInfo) attributes() map[string]string {
+	attributes := map[string]string{}
+	for key, value := range map[string]string{
+		GeoCountry:     g.Country,
+		GeoCountryName: g.CountryName,
+		GeoCity:        g.City,
+		GeoASOrg:       g.ASOrg,
+	} {
+		if value != "" {
+			attributes[key] = value
+		}
+	}
+
+	if g.ASN != 0 {
+		attributes[GeoASN] = strconv.FormatUint(uint64(g.ASN), 10)
+	}
+	return attributes
+}
+
+// geoReader looks up IPs in a MaxMind DB.
+type geoReader interface {
+	Lookup(ip net.IP, result any) error
+	Close() error
+}
+
+// GeoIP looks up IPs in local MaxMind DB (.mmdb) files, like the GeoLite2
+// City and ASN databases. It never does network lookups.
+type GeoIP struct {
+	readers []geoReader
+}
+
+// OpenGeoIP opens the MaxMind DB files. Lookups combine the fields of every
+// database, so a City and an ASN database can be used together.
+func OpenGeoIP(paths ...string) (*GeoIP, error) {
+	geoIP := &GeoIP{}
+	for _, path := range paths {
+		reader, err := maxminddb.Open(path)
+		if err != nil {
+			geoIP.Close()
+			return nil, fmt.Errorf("unable to open %s: %w", path, err)
+		}
+		geoIP.readers = append(geoIP.readers, reader)
+	}
+	return geoIP, nil
+}
+
+// Close closes the databases.
+func (g *GeoIP) Close() error {
+	var err error
+	for _, reader := range g.readers {
+		err = cmp.Or(reader.Close(), err)
+	}
+	return err
+}
+
+// Lookup returns what the databases know about the address. Private and
+// other non-public addresses are not looked up.
+func (g *GeoIP) Lookup(addr string) (GeoInfo, bool) {
+	ip := net.ParseIP(addr)
+	if ip == nil || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+		return GeoInfo{}, false
+	}
+
+	var info GeoInfo
+	for _, reader := range g.readers {
+		var record geoRecord
+		if reader.Lookup(ip, &record) != nil {
+			continue
+		}
+
+		info.Country = cmp.Or(info.Country, record.Country.ISOCode)
+		info.CountryName = cmp.Or(info.CountryName, record.Country.Names["en"])
+		info.City = cmp.Or(info.City, record.City.Names["en"])
+		info.ASN = cmp.Or(info.ASN, record.AutonomousSystemNumber)
+		info.ASOrg = cmp.Or(info.ASOrg, record.AutonomousSystemOrganization)
+	}
+	return info, info != GeoInfo{}
+}
+
+// hostGeo returns the GeoIP info of the first address of the host found in
+// the databases.
+func (g *GeoIP) hostGeo(h *nmap.Host) (GeoInfo, bool) {
+	for _, addr := range h.Addresses {
+		if info, ok := g.Lookup(addr.Addr); ok {
+			return info, true
+		}
+	}
+	return GeoInfo{}, false
+}
+
+// SetGeoIP sets the databases used to add the country, city, ASN and AS
+// organization of public IPs to host attributes.
+func (v *View) SetGeoIP(geoIP *GeoIP) {
+	v.geoIP = geoIP
+}
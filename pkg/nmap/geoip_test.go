@@ -0,0 +1,105 @@
+package nmap
+
+import (
+	"maps"
+	"net"
+	"testing"
+)
+
+// fakeGeoReader is a MaxMind DB with records for single IPs.
+type fakeGeoReader map[string]geoRecord
+
+func (r fakeGeoReader) Lookup(ip net.IP, result any) error {
+	*result.(*geoRecord) = r[ip.String()]
+	return nil
+}
+
+func (r fakeGeoReader) Close() error {
+	return nil
+}
+
+func testGeoIP() *GeoIP {
+	var city geoRecord
+	city.Country.ISOCode = "US"
+	city.Country.Names = map[string]string{"en": "United States", "de": "USA"}
+	city.City.Names = map[string]string{"en": "Mountain View"}
+
+	var asn geoRecord
+	asn.AutonomousSystemNumber = 15169
+	asn.AutonomousSystemOrganization = "GOOGLE"
+
+	var private geoRecord
+	private.AutonomousSystemNumber = 64512
+
+	return &GeoIP{readers: []geoReader{
+		fakeGeoReader{"8.8.8.8": city, "10.0.0.1": private},
+		fakeGeoReader{"8.8.8.8": asn, "1.1.1.1": {AutonomousSystemNumber: 13335}},
+	}}
+}
+
+func TestGeoIPLookup(t *testing.T) {
+	tests := []struct {
+		addr   string
+		want   GeoInfo
+		wantOK bool
+	}{
+		{
+			addr:   "8.8.8.8",
+			want:   GeoInfo{Country: "US", CountryName: "United States", City: "Mountain View", ASN: 15169, ASOrg: "GOOGLE"},
+			wantOK: true,
+		},
+		{addr: "1.1.1.1", want: GeoInfo{ASN: 13335}, wantOK: true},
+		{addr: "9.9.9.9"},
+		{addr: "10.0.0.1"},
+		{addr: "not an ip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			got, ok := testGeoIP().Lookup(tt.addr)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Lookup() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGeoIPAttributes(t *testing.T) {
+	view := assetsTestView(t)
+	view.SetGeoIP(testGeoIP())
+
+	want := map[string]string{
+		"country":      "US",
+		"country_name": "United States",
+		"city":         "Mountain View",
+		"asn":          "15169",
+		"as_org":       "GOOGLE",
+	}
+
+	hosts := view.GetHosts()
+	if got := view.HostAttributes(hosts[1]); !maps.Equal(got, want) {
+		t.Errorf("HostAttributes() = %v, want %v", got, want)
+	}
+
+	if got := view.HostAttributes(hosts[0])[GeoASN]; got != "" {
+		t.Errorf("HostAttributes() asn of a private host = %q, want none", got)
+	}
+
+	err := view.SetColumns([]string{"owner", "asn", "as_org"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	condition, err := ParseWhereCondition("asn == 15169")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = view.SetWhere([]WhereCondition{condition})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, rows := view.tableRows("IP", 0)
+	if len(rows) != 1 || rows[0][0] != "8.8.8.8" || rows[0][5] != "15169" || rows[0][6] != "GOOGLE" {
+		t.Errorf("tableRows() = %q", rows)
+	}
+}
@@ -0,0 +1,150 @@
+package nmap
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+// MatchLine is a line of script output, either matching the search or shown
+// as context around a match.
+type MatchLine struct {
+	Number int    `json:"number"`
+	Text   string `json:"text"`
+	Match  bool   `json:"match"`
+}
+
+// ScriptMatch is a host or port script matching a search.
+type ScriptMatch struct {
+	IPs       []string    `json:"ips"`
+	Hostnames []string    `json:"hostnames"`
+	Port      string      `json:"port,omitempty"`
+	ScriptID  string      `json:"script_id"`
+	MatchedID bool        `json:"matched_id"`
+	Lines     []MatchLine `json:"lines"`
+}
+
+// GrepOptions restricts which scripts are searched and how many lines of
+// context are kept around each matching line.
+type GrepOptions struct {
+	Scripts []string
+	Context int
+}
+
+// GrepScripts searches the ID, output and structured elements of every host
+// and port script.
+func (v *View) GrepScripts(re *regexp.Regexp, options ViewOptions, grepOptions GrepOptions) []ScriptMatch {
+	var matches []ScriptMatch
+	for _, h := range v.GetHostsWithOptions(options) {
+		hostnames, ips := hostnamesAndIPs(h)
+
+		grep := func(port string, script nmap.Script) {
+			if strings.HasPrefix(script.ID, nexScriptPrefix) {
+				return
+			}
+
+			if len(grepOptions.Scripts) > 0 && !slices.Contains(grepOptions.Scripts, script.ID) {
+				return
+			}
+
+			matchedID := re.MatchString(script.ID)
+			lines := grepLines(scriptLines(script), re, grepOptions.Context)
+			if !matchedID && len(lines) == 0 {
+				return
+			}
+
+			matches = append(matches, ScriptMatch{
+				IPs:       ips,
+				Hostnames: hostnames,
+				Port:      port,
+				ScriptID:  script.ID,
+				MatchedID: matchedID,
+				Lines:     lines,
+			})
+		}
+
+		for _, script := range h.HostScripts {
+			grep("", script)
+		}
+
+		for _, port := range h.Ports {
+			for _, script := range port.Scripts {
+				grep(fmt.Sprintf("%d/%s", port.ID, newPortKey(port).protocol), script)
+			}
+		}
+	}
+	return matches
+}
+
+// scriptLines returns the lines of the script output followed by its
+// elements and tables as "path.key: value" lines.
+func scriptLines(script nmap.Script) []string {
+	lines := strings.Split(strings.TrimSpace(script.Output), "\n")
+	lines = appendElementLines(lines, "", script.Elements)
+	for _, table := range script.Tables {
+		lines = appendTableLines(lines, "", table)
+	}
+	return lines
+}
+
+func appendTableLines(lines []string, path string, table nmap.Table) []string {
+	path = joinKey(path, table.Key)
+	lines = appendElementLines(lines, path, table.Elements)
+	for _, t := range table.Tables {
+		lines = appendTableLines(lines, path, t)
+	}
+	return lines
+}
+
+func appendElementLines(lines []string, path string, elements []nmap.Element) []string {
+	for _, elem := range elements {
+		key := joinKey(path, elem.Key)
+		value := strings.TrimSpace(html.UnescapeString(elem.Value))
+		if key != "" {
+			value = fmt.Sprintf("%s: %s", key, value)
+		}
+		lines = append(lines, strings.Split(value, "\n")...)
+	}
+	return lines
+}
+
+func joinKey(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	if key == "" {
+		return path
+	}
+	return path + "." + key
+}
+
+// grepLines returns the lines matching re along with up to context lines
+// before and after each of them.
+func grepLines(lines []string, re *regexp.Regexp, context int) []MatchLine {
+	var matched []int
+	for i, line := range lines {
+		if re.MatchString(line) {
+			matched = append(matched, i)
+		}
+	}
+
+	var result []MatchLine
+	next := 0 // first line not added yet
+	for _, i := range matched {
+		start := max(i-context, next)
+		end := min(i+context, len(lines)-1)
+		for j := start; j <= end; j++ {
+			result = append(result, MatchLine{
+				Number: j + 1,
+				Text:   lines[j],
+				Match:  slices.Contains(matched, j),
+			})
+		}
+		next = max(next, end+1)
+	}
+	return result
+}
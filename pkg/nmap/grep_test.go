@@ -0,0 +1,95 @@
+package nmap
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+func TestGrepLines(t *testing.T) {
+	lines := []string{"a", "match 1", "b", "c", "d", "match 2", "match 3", "e"}
+	tests := []struct {
+		name    string
+		context int
+		want    []int
+	}{
+		{
+			name: "no context",
+			want: []int{2, 6, 7},
+		},
+		{
+			name:    "overlapping context is not repeated",
+			context: 1,
+			want:    []int{1, 2, 3, 5, 6, 7, 8},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, line := range grepLines(lines, regexp.MustCompile("match"), tt.context) {
+				got = append(got, line.Number)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("grepLines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrepScripts(t *testing.T) {
+	run := &nmap.Run{Hosts: []nmap.Host{{
+		Status:    nmap.Status{State: "up"},
+		Addresses: []nmap.Address{{Addr: "10.0.0.1", AddrType: "ipv4"}},
+		HostScripts: []nmap.Script{
+			{ID: "smb-os-discovery", Output: "OS: Windows Server 2016"},
+		},
+		Ports: []nmap.Port{{
+			ID:       443,
+			Protocol: "tcp",
+			State:    nmap.State{State: "open"},
+			Scripts: []nmap.Script{
+				{ID: "ssl-cert", Output: "Subject: commonName=example.com", Tables: []nmap.Table{
+					{Key: "issuer", Elements: []nmap.Element{{Key: "commonName", Value: "Windows CA"}}},
+				}},
+				{ID: lastConfirmedScriptID, Output: "Windows"},
+			},
+		}},
+	}}}
+
+	tests := []struct {
+		name    string
+		pattern string
+		scripts []string
+		want    []string
+	}{
+		{
+			name:    "outputs and elements",
+			pattern: "Windows",
+			want:    []string{"smb-os-discovery:OS: Windows Server 2016", "ssl-cert:issuer.commonName: Windows CA"},
+		},
+		{
+			name:    "restricted to script",
+			pattern: "Windows",
+			scripts: []string{"ssl-cert"},
+			want:    []string{"ssl-cert:issuer.commonName: Windows CA"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewNmapView(run)
+			var got []string
+			for _, match := range v.GrepScripts(regexp.MustCompile(tt.pattern), 0, GrepOptions{Scripts: tt.scripts}) {
+				for _, line := range match.Lines {
+					got = append(got, match.ScriptID+":"+line.Text)
+				}
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GrepScripts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,22 @@
 package nmap
 
+import (
+	"slices"
+	"strings"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
 type Options struct {
-	upOnly   bool
-	openOnly bool
+	upOnly       bool
+	openOnly     bool
+	publicOnly   bool
+	privateOnly  bool
+	noTCPWrapped bool
+	includePorts []int
+	excludePorts []int
+	hostFilter   func(hostnames []string, ips []string) bool
+	noTimestamps bool
 }
 
 type Option func(*Options)
@@ -18,3 +32,111 @@ func WithOpenOnly() Option {
 		o.openOnly = true
 	}
 }
+
+func WithPublicOnly() Option {
+	return func(o *Options) {
+		o.publicOnly = true
+	}
+}
+
+func WithPrivateOnly() Option {
+	return func(o *Options) {
+		o.privateOnly = true
+	}
+}
+
+func WithoutTCPWrapped() Option {
+	return func(o *Options) {
+		o.noTCPWrapped = true
+	}
+}
+
+func WithIncludePorts(ports []int) Option {
+	return func(o *Options) {
+		o.includePorts = ports
+	}
+}
+
+func WithExcludePorts(ports []int) Option {
+	return func(o *Options) {
+		o.excludePorts = ports
+	}
+}
+
+func WithHosts(include []string, exclude []string) Option {
+	return func(o *Options) {
+		o.hostFilter = HostListFilter(include, exclude)
+	}
+}
+
+// WithoutTimestamps leaves the last confirmed timestamps nex adds to ports
+// out of the merged run, for tools that don't expect them. Merging the result
+// again can then no longer tell which ports are stale.
+func WithoutTimestamps() Option {
+	return func(o *Options) {
+		o.noTimestamps = true
+	}
+}
+
+// filterHost applies the merge-time filters to a merged host. It returns the
+// host with unwanted ports removed and whether the host should be kept.
+func (o *Options) filterHost(h nmap.Host) (nmap.Host, bool) {
+	if o.hostFilter != nil && !o.hostFilter(hostnamesAndIPs(&h)) {
+		return h, false
+	}
+
+	hasPrivateIPs, hasPublicIPs := addressKinds(&h)
+	if o.privateOnly && !hasPrivateIPs {
+		return h, false
+	}
+
+	if o.publicOnly && !hasPublicIPs {
+		return h, false
+	}
+
+	hadOpenPorts := hasOpenPorts(&h)
+	if o.upOnly && h.Status.State != "up" && !hadOpenPorts {
+		return h, false
+	}
+
+	var ports []nmap.Port
+	for _, p := range h.Ports {
+		portID := int(p.ID)
+		if slices.Contains(o.excludePorts, portID) {
+			continue
+		}
+
+		if len(o.includePorts) > 0 && !slices.Contains(o.includePorts, portID) {
+			continue
+		}
+
+		if o.noTCPWrapped && p.Service.Name == "tcpwrapped" {
+			continue
+		}
+
+		if o.openOnly && !strings.Contains(p.State.State, "open") {
+			continue
+		}
+
+		ports = append(ports, p)
+	}
+	h.Ports = ports
+	if o.noTimestamps {
+		h.Ports = withoutNexScripts(h.Ports)
+	}
+
+	if len(o.includePorts) > 0 && len(ports) == 0 {
+		return h, false
+	}
+
+	if o.openOnly && len(ports) == 0 {
+		return h, false
+	}
+
+	// Skip hosts that only had filtered out ports open
+	if hadOpenPorts && !hasOpenPorts(&h) {
+		return h, false
+	}
+
+	return h, true
+}
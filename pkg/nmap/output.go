@@ -0,0 +1,81 @@
+package nmap
+
+import (
+	"log"
+	"strings"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+// ScriptOutput is a script along with its typed value, for scripts nex has a
+// parser for.
+type ScriptOutput struct {
+	nmap.Script
+	Parsed any `json:"parsed,omitempty"`
+}
+
+// PortOutput is a port as shown in JSON output.
+type PortOutput struct {
+	nmap.Port
+	Scripts    []ScriptOutput `json:"scripts"`
+	CVEs       []CVEMatch     `json:"cves,omitempty"`
+	Annotation *Annotation    `json:"annotation,omitempty"`
+}
+
+// HostOutput is a host as shown in JSON output, with the parsed script
+// output added to its scripts, the CVEs matched to its ports, the
+// annotations of the host and ports, the host attributes and the names
+// found in DNS records, kept apart from the hostnames nmap recorded.
+type HostOutput struct {
+	nmap.Host
+	HostScripts []ScriptOutput    `json:"host_scripts"`
+	Ports       []PortOutput      `json:"ports"`
+	Annotation  *Annotation       `json:"annotation,omitempty"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	DNSNames    []DNSName         `json:"dns_names,omitempty"`
+}
+
+func (v *View) hostOutput(h *nmap.Host) HostOutput {
+	output := HostOutput{
+		Host:        *h,
+		HostScripts: newScriptOutputs(h.HostScripts),
+		Attributes:  v.HostAttributes(h),
+		DNSNames:    v.dnsRecords.hostNames(h),
+	}
+
+	if hostAnnotation := v.annotations.hostAnnotation(h); hostAnnotation != nil {
+		output.Annotation = &hostAnnotation.Annotation
+	}
+
+	for _, port := range h.Ports {
+		portOutput := PortOutput{
+			Port:       port,
+			Scripts:    newScriptOutputs(port.Scripts),
+			Annotation: v.annotations.portAnnotation(h, &port),
+		}
+
+		if v.cveIndex != nil {
+			portOutput.CVEs = v.cveIndex.Match(port.Service)
+		}
+		output.Ports = append(output.Ports, portOutput)
+	}
+	return output
+}
+
+// newScriptOutputs parses the scripts, leaving out the pseudo scripts nex
+// adds to merged scans.
+func newScriptOutputs(scripts []nmap.Script) []ScriptOutput {
+	var outputs []ScriptOutput
+	for _, script := range scripts {
+		if strings.HasPrefix(script.ID, nexScriptPrefix) {
+			continue
+		}
+
+		parsed, err := ParseScript(script)
+		if err != nil {
+			log.Printf("[!] Unable to parse %s output: %s", script.ID, err)
+		}
+		outputs = append(outputs, ScriptOutput{Script: script, Parsed: parsed})
+	}
+	return outputs
+}
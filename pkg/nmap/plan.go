@@ -0,0 +1,192 @@
+package nmap
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+// PlannedScan is a follow-up nmap scan suggested by Plan.
+type PlannedScan struct {
+	Reason string   `json:"reason"`
+	Args   []string `json:"args"`
+	Hosts  []string `json:"hosts"`
+}
+
+// Command returns the nmap command line for the planned scan.
+func (p PlannedScan) Command() string {
+	args := append([]string{"nmap"}, p.Args...)
+	return strings.Join(append(args, p.Hosts...), " ")
+}
+
+type PlanOptions struct {
+	// UDPTopPorts is the number of top UDP ports to scan on hosts without
+	// any UDP coverage.
+	UDPTopPorts int
+	// OutputPrefix is used to name the -oA output of each planned scan.
+	OutputPrefix string
+}
+
+// hostPlan is what a single host still needs.
+type hostPlan struct {
+	unprobed     []Target
+	needsFullTCP bool
+	needsUDP     bool
+	firewalled   bool
+}
+
+// Plan suggests follow-up scans for the matching hosts that are up, based on
+// what the previous scans covered:
+//   - open ports without version detection get a service and script scan
+//   - hosts that had every scanned port filtered are rescanned without ping
+//   - hosts without a full TCP port range get a full range scan
+//   - hosts without UDP coverage get a top ports UDP scan
+//
+// Hosts needing the same scan are grouped, and service scans are grouped by
+// identical port sets, to keep the number of nmap invocations low.
+func (v *View) Plan(coverage map[string]Coverage, options ViewOptions, planOptions PlanOptions) []PlannedScan {
+	var planned []PlannedScan
+	for _, ipv6 := range []bool{false, true} {
+		var unprobed []Target
+		var fullTCP, udp, firewalledFullTCP, firewalledACK []string
+
+		for _, h := range v.GetHostsWithOptions(options | ViewAliveHosts) {
+			target, isIPv6 := planTarget(h)
+			if target == "" || isIPv6 != ipv6 {
+				continue
+			}
+
+			hp := planHost(h, target, hostCoverage(h, coverage))
+			unprobed = append(unprobed, hp.unprobed...)
+
+			switch {
+			case hp.firewalled && hp.needsFullTCP:
+				firewalledFullTCP = append(firewalledFullTCP, target)
+			case hp.firewalled:
+				firewalledACK = append(firewalledACK, target)
+			case hp.needsFullTCP:
+				fullTCP = append(fullTCP, target)
+			}
+
+			if hp.needsUDP {
+				udp = append(udp, target)
+			}
+		}
+
+		var familyArgs []string
+		if ipv6 {
+			familyArgs = []string{"-6"}
+		}
+
+		for _, group := range GroupTargetsByPorts(unprobed) {
+			args := append(append([]string{}, familyArgs...), group.ScanTypes()...)
+			args = append(args, "-sV", "-sC", "-p", group.PortList())
+
+			planned = append(planned, PlannedScan{
+				Reason: "open ports without version detection",
+				Args:   args,
+				Hosts:  group.Hosts,
+			})
+		}
+
+		hostScans := []PlannedScan{
+			{
+				Reason: "every scanned port was filtered, scanning all ports without host discovery",
+				Args:   []string{"-Pn", "-p-", "--reason"},
+				Hosts:  firewalledFullTCP,
+			},
+			{
+				Reason: "every port was filtered, mapping the firewall rules with an ACK scan",
+				Args:   []string{"-Pn", "-sA", "--top-ports", "1000", "--reason"},
+				Hosts:  firewalledACK,
+			},
+			{
+				Reason: "the full TCP port range was not scanned",
+				Args:   []string{"-p-"},
+				Hosts:  fullTCP,
+			},
+			{
+				Reason: "no UDP ports were scanned",
+				Args:   []string{"-sU", "--top-ports", fmt.Sprint(planOptions.UDPTopPorts)},
+				Hosts:  udp,
+			},
+		}
+		for _, scan := range hostScans {
+			if len(scan.Hosts) > 0 {
+				scan.Args = append(append([]string{}, familyArgs...), scan.Args...)
+				planned = append(planned, scan)
+			}
+		}
+	}
+
+	if planOptions.OutputPrefix != "" {
+		for i := range planned {
+			planned[i].Args = append(planned[i].Args, "-oA", fmt.Sprintf("%s-%d", planOptions.OutputPrefix, i+1))
+		}
+	}
+
+	return planned
+}
+
+func planHost(h *nmap.Host, target string, coverage Coverage) hostPlan {
+	hp := hostPlan{
+		needsFullTCP: !coverage[ProtocolTCP].IsFullRange(),
+		needsUDP:     len(coverage[ProtocolUDP]) == 0,
+	}
+
+	respondingPorts := 0
+	for _, port := range h.Ports {
+		if port.State.State != string(nmap.Filtered) {
+			respondingPorts++
+		}
+
+		if !portIsOpen(&port) || port.Service.Method == "probed" {
+			continue
+		}
+
+		// IP protocol scans report protocol numbers, not ports
+		if strings.EqualFold(port.Protocol, ProtocolIP) {
+			continue
+		}
+
+		hp.unprobed = append(hp.unprobed, Target{
+			Host:     target,
+			Port:     port.ID,
+			Protocol: strings.ToLower(port.Protocol),
+			Service:  port.Service.Name,
+		})
+	}
+
+	allFiltered := len(h.ExtraPorts) > 0
+	for _, extra := range h.ExtraPorts {
+		if extra.State != string(nmap.Filtered) {
+			allFiltered = false
+		}
+	}
+	hp.firewalled = respondingPorts == 0 && allFiltered
+
+	return hp
+}
+
+// planTarget returns the address to scan the host by and whether it is an
+// IPv6 address.
+func planTarget(h *nmap.Host) (string, bool) {
+	for _, addr := range h.Addresses {
+		ip := net.ParseIP(addr.Addr)
+		if ip != nil {
+			return addr.Addr, ip.To4() == nil
+		}
+	}
+	return "", false
+}
+
+// hostCoverage returns the ports scanned on any of the host's addresses.
+func hostCoverage(h *nmap.Host, coverage map[string]Coverage) Coverage {
+	hc := Coverage{}
+	for _, addr := range h.Addresses {
+		hc.Add(coverage[addr.Addr])
+	}
+	return hc
+}
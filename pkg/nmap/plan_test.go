@@ -0,0 +1,59 @@
+package nmap
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+const planTestXML = `<?xml version="1.0" encoding="UTF-8"?>
+<nmaprun scanner="nmap" args="nmap" start="1000" version="7.94" xmloutputversion="1.05">
+<scaninfo type="syn" protocol="tcp" numservices="1000" services="1-1000"/>
+<host><status state="up" reason="syn-ack"/>
+<address addr="10.0.0.1" addrtype="ipv4"/>
+<ports>
+<port protocol="tcp" portid="22"><state state="open" reason="syn-ack"/><service name="ssh" method="table" conf="3"/></port>
+<port protocol="tcp" portid="80"><state state="open" reason="syn-ack"/><service name="http" method="table" conf="3"/></port>
+</ports>
+</host>
+<host><status state="up" reason="syn-ack"/>
+<address addr="10.0.0.2" addrtype="ipv4"/>
+<ports>
+<port protocol="tcp" portid="22"><state state="open" reason="syn-ack"/><service name="ssh" method="table" conf="3"/></port>
+<port protocol="tcp" portid="80"><state state="open" reason="syn-ack"/><service name="http" method="table" conf="3"/></port>
+<port protocol="tcp" portid="443"><state state="open" reason="syn-ack"/><service name="https" method="probed" conf="10"/></port>
+</ports>
+</host>
+<host><status state="up" reason="echo-reply"/>
+<address addr="10.0.0.3" addrtype="ipv4"/>
+<ports><extraports state="filtered" count="1000"/></ports>
+</host>
+</nmaprun>`
+
+func TestViewPlan(t *testing.T) {
+	run, err := nmap.Parse([]byte(planTestXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	coverage := map[string]Coverage{}
+	for _, h := range run.Hosts {
+		coverage[h.Addresses[0].Addr] = Coverage{ProtocolTCP: parsePortRanges("1-1000")}
+	}
+
+	var got []string
+	for _, scan := range NewNmapView(run).Plan(coverage, 0, PlanOptions{UDPTopPorts: 100}) {
+		got = append(got, scan.Command())
+	}
+
+	want := []string{
+		"nmap -sV -sC -p 22,80 10.0.0.1 10.0.0.2",
+		"nmap -Pn -p- --reason 10.0.0.3",
+		"nmap -p- 10.0.0.1 10.0.0.2",
+		"nmap -sU --top-ports 100 10.0.0.1 10.0.0.2 10.0.0.3",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Plan() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,52 @@
+package nmap
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+// Protocols nmap reports ports for, in the order they are displayed. Ports
+// of an IP protocol scan (-sO) are protocol numbers rather than ports.
+const (
+	ProtocolTCP  = "tcp"
+	ProtocolUDP  = "udp"
+	ProtocolSCTP = "sctp"
+	ProtocolIP   = "ip"
+)
+
+var protocols = []string{ProtocolTCP, ProtocolUDP, ProtocolSCTP, ProtocolIP}
+
+// portKey identifies a port on a host. Port numbers are only unique per
+// protocol, so 53/tcp and 53/udp are different ports.
+type portKey struct {
+	protocol string
+	id       uint16
+}
+
+func newPortKey(port nmap.Port) portKey {
+	return portKey{
+		protocol: strings.ToLower(port.Protocol),
+		id:       port.ID,
+	}
+}
+
+// protocolIndex returns the display position of the protocol. Unknown
+// protocols are sorted last.
+func protocolIndex(protocol string) int {
+	i := slices.Index(protocols, strings.ToLower(protocol))
+	if i == -1 {
+		return len(protocols)
+	}
+	return i
+}
+
+func sortPorts(ports []nmap.Port) {
+	slices.SortStableFunc(ports, func(a, b nmap.Port) int {
+		if a.ID != b.ID {
+			return int(a.ID) - int(b.ID)
+		}
+		return protocolIndex(a.Protocol) - protocolIndex(b.Protocol)
+	})
+}
@@ -0,0 +1,384 @@
+package nmap
+
+import (
+	"cmp"
+	_ "embed"
+	"fmt"
+	"net/netip"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Ullaakut/nmap/v2"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed rules.yaml
+var defaultRules []byte
+
+// Severities of rules, from least to most severe.
+const (
+	SeverityInfo     = "info"
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+var severities = []string{SeverityInfo, SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+// SeverityRank returns how severe a severity is, -1 if it is unknown.
+func SeverityRank(severity string) int {
+	return slices.Index(severities, strings.ToLower(severity))
+}
+
+// RuleMatch is what a rule matches in the host/port/script model. Every
+// condition given has to match. A port matches Ports and Services when it
+// is one of the ports or runs one of the services.
+type RuleMatch struct {
+	Ports        []int    `yaml:"ports,omitempty" json:"ports,omitempty"`
+	NotPorts     []int    `yaml:"not_ports,omitempty" json:"not_ports,omitempty"`
+	Services     []string `yaml:"services,omitempty" json:"services,omitempty"`
+	Protocol     string   `yaml:"protocol,omitempty" json:"protocol,omitempty"`
+	Product      string   `yaml:"product,omitempty" json:"product,omitempty"`
+	Version      string   `yaml:"version,omitempty" json:"version,omitempty"`
+	Public       *bool    `yaml:"public,omitempty" json:"public,omitempty"`
+	CIDRs        []string `yaml:"cidrs,omitempty" json:"cidrs,omitempty"`
+	Script       string   `yaml:"script,omitempty" json:"script,omitempty"`
+	ScriptOutput string   `yaml:"script_output,omitempty" json:"script_output,omitempty"`
+
+	ranges   []addrRange
+	versions VersionConstraints
+	outputRe *regexp.Regexp
+}
+
+func (m *RuleMatch) compile() error {
+	m.ranges = nil
+	for _, cidr := range m.CIDRs {
+		r, ok := parseAddrRange(strings.TrimSpace(cidr))
+		if !ok {
+			return fmt.Errorf("invalid IP, CIDR or range %q", cidr)
+		}
+		m.ranges = append(m.ranges, r)
+	}
+
+	var err error
+	m.versions, err = ParseVersionConstraints(m.Version)
+	if err != nil {
+		return err
+	}
+
+	m.outputRe = nil
+	if m.ScriptOutput != "" {
+		m.outputRe, err = regexp.Compile(m.ScriptOutput)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isPortRule reports whether the match is about open ports.
+func (m *RuleMatch) isPortRule() bool {
+	return len(m.Ports) > 0 || len(m.NotPorts) > 0 || len(m.Services) > 0 ||
+		m.Protocol != "" || m.Product != "" || m.Version != ""
+}
+
+// ruleHit is a host, and the port if it is a port rule, a rule matched.
+type ruleHit struct {
+	host     *nmap.Host
+	port     *nmap.Port
+	evidence string
+}
+
+// hits returns everything on the host the conditions match.
+func (m *RuleMatch) hits(h *nmap.Host) []ruleHit {
+	if m.Public != nil {
+		_, hasPublicIPs := addressKinds(h)
+		if *m.Public != hasPublicIPs {
+			return nil
+		}
+	}
+
+	if len(m.ranges) > 0 && !hostInRanges(h, m.ranges) {
+		return nil
+	}
+
+	var hits []ruleHit
+	if m.isPortRule() {
+		for i := range h.Ports {
+			port := &h.Ports[i]
+			if !portIsOpen(port) || !m.matchesPort(port) {
+				continue
+			}
+
+			evidence := portDescription(port)
+			if m.Script != "" || m.outputRe != nil {
+				scriptEvidence, ok := m.matchScripts(port.Scripts)
+				if !ok {
+					continue
+				}
+				evidence = fmt.Sprintf("%s: %s", evidence, scriptEvidence)
+			}
+			hits = append(hits, ruleHit{host: h, port: port, evidence: evidence})
+		}
+		return hits
+	}
+
+	if m.Script != "" || m.outputRe != nil {
+		if evidence, ok := m.matchScripts(h.HostScripts); ok {
+			hits = append(hits, ruleHit{host: h, evidence: evidence})
+		}
+
+		for i := range h.Ports {
+			port := &h.Ports[i]
+			if !portIsOpen(port) {
+				continue
+			}
+
+			if evidence, ok := m.matchScripts(port.Scripts); ok {
+				hits = append(hits, ruleHit{host: h, port: port, evidence: fmt.Sprintf("%s: %s", portDescription(port), evidence)})
+			}
+		}
+		return hits
+	}
+
+	if h.Status.State == "up" || hasOpenPorts(h) {
+		hits = append(hits, ruleHit{host: h, evidence: "host is up"})
+	}
+	return hits
+}
+
+func (m *RuleMatch) matchesPort(port *nmap.Port) bool {
+	if len(m.Ports) > 0 || len(m.Services) > 0 {
+		inPorts := slices.Contains(m.Ports, int(port.ID))
+		inServices := slices.ContainsFunc(m.Services, func(service string) bool {
+			return strings.EqualFold(service, port.Service.Name)
+		})
+
+		if !inPorts && !inServices {
+			return false
+		}
+	}
+
+	if slices.Contains(m.NotPorts, int(port.ID)) {
+		return false
+	}
+
+	if m.Protocol != "" && !strings.EqualFold(m.Protocol, newPortKey(*port).protocol) {
+		return false
+	}
+
+	if m.Product != "" && !strings.Contains(strings.ToLower(port.Service.Product), strings.ToLower(m.Product)) {
+		return false
+	}
+
+	return m.versions.Matches(port.Service.Version)
+}
+
+// matchScripts returns the first matching line of the first script matching
+// the script conditions.
+func (m *RuleMatch) matchScripts(scripts []nmap.Script) (string, bool) {
+	for _, script := range scripts {
+		if strings.HasPrefix(script.ID, nexScriptPrefix) {
+			continue
+		}
+
+		if m.Script != "" && script.ID != m.Script {
+			continue
+		}
+
+		if m.outputRe == nil {
+			return script.ID, true
+		}
+
+		for _, line := range scriptLines(script) {
+			if m.outputRe.MatchString(line) {
+				return fmt.Sprintf("%s: %s", script.ID, strings.TrimSpace(line)), true
+			}
+		}
+	}
+	return "", false
+}
+
+func hostInRanges(h *nmap.Host, ranges []addrRange) bool {
+	for _, a := range h.Addresses {
+		addr, err := netip.ParseAddr(a.Addr)
+		if err != nil {
+			continue
+		}
+
+		for _, r := range ranges {
+			if r.contains(addr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// portDescription describes a port like "22/tcp ssh OpenSSH 7.4".
+func portDescription(port *nmap.Port) string {
+	return strings.TrimSpace(fmt.Sprintf("%d/%s %s %s", port.ID, newPortKey(*port).protocol, port.Service.Name, serviceProduct(port.Service)))
+}
+
+// Rule flags hosts and ports matching its conditions as findings.
+type Rule struct {
+	ID          string    `yaml:"id" json:"id"`
+	Title       string    `yaml:"title" json:"title"`
+	Severity    string    `yaml:"severity" json:"severity"`
+	Description string    `yaml:"description,omitempty" json:"description,omitempty"`
+	Disabled    bool      `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+	Match       RuleMatch `yaml:"match" json:"match"`
+}
+
+func (r *Rule) compile() error {
+	if r.ID == "" {
+		return fmt.Errorf("rule %q is missing an id", r.Title)
+	}
+
+	if SeverityRank(r.Severity) == -1 {
+		return fmt.Errorf("rule %s has unknown severity %q, expected one of: %s", r.ID, r.Severity, strings.Join(severities, ", "))
+	}
+
+	err := r.Match.compile()
+	if err != nil {
+		return fmt.Errorf("rule %s: %w", r.ID, err)
+	}
+	return nil
+}
+
+func parseRules(data []byte) ([]*Rule, error) {
+	var file struct {
+		Rules []*Rule `yaml:"rules"`
+	}
+
+	err := yaml.Unmarshal(data, &file)
+	if err != nil {
+		return nil, err
+	}
+	return file.Rules, nil
+}
+
+// LoadRules returns the built in rules, if includeDefaults is set, along with
+// the rules in the YAML files. A rule with the ID of an earlier rule replaces
+// it, so built in rules can be changed or disabled.
+func LoadRules(includeDefaults bool, paths ...string) ([]*Rule, error) {
+	var rules []*Rule
+	add := func(data []byte, source string) error {
+		parsed, err := parseRules(data)
+		if err != nil {
+			return fmt.Errorf("unable to parse rules from %s: %w", source, err)
+		}
+
+		for _, rule := range parsed {
+			// disabling a rule only needs its id
+			if !rule.Disabled {
+				err = rule.compile()
+				if err != nil {
+					return fmt.Errorf("invalid rule in %s: %w", source, err)
+				}
+			}
+
+			i := slices.IndexFunc(rules, func(r *Rule) bool { return r.ID == rule.ID })
+			if i == -1 {
+				rules = append(rules, rule)
+			} else {
+				rules[i] = rule
+			}
+		}
+		return nil
+	}
+
+	if includeDefaults {
+		err := add(defaultRules, "the built in rules")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		err = add(data, path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return slices.DeleteFunc(rules, func(r *Rule) bool { return r.Disabled }), nil
+}
+
+// Finding is a host or port matched by a rule.
+type Finding struct {
+	RuleID      string   `json:"rule_id"`
+	Title       string   `json:"title"`
+	Severity    string   `json:"severity"`
+	Description string   `json:"description,omitempty"`
+	Host        string   `json:"host"`
+	Hostnames   []string `json:"hostnames,omitempty"`
+	Port        uint16   `json:"port,omitempty"`
+	Protocol    string   `json:"protocol,omitempty"`
+	Service     string   `json:"service,omitempty"`
+	Evidence    string   `json:"evidence"`
+}
+
+// Location describes where the finding is, like "10.0.0.1:22/tcp".
+func (f Finding) Location() string {
+	if f.Port == 0 {
+		return f.Host
+	}
+	return fmt.Sprintf("%s:%d/%s", f.Host, f.Port, f.Protocol)
+}
+
+// GetFindings returns what the rules match on the hosts, most severe first.
+func (v *View) GetFindings(rules []*Rule, options ViewOptions) []Finding {
+	var findings []Finding
+	for _, h := range v.GetHostsWithOptions(options) {
+		for _, rule := range rules {
+			findings = append(findings, rule.findings(h)...)
+		}
+	}
+
+	sortFindings(findings)
+	return findings
+}
+
+// findings returns what the rule matches on the host.
+func (r *Rule) findings(h *nmap.Host) []Finding {
+	var findings []Finding
+	hostnames, _ := hostnamesAndIPs(h)
+	for _, hit := range r.Match.hits(h) {
+		finding := Finding{
+			RuleID:      r.ID,
+			Title:       r.Title,
+			Severity:    strings.ToLower(r.Severity),
+			Description: r.Description,
+			Host:        h.Addresses[0].Addr,
+			Hostnames:   hostnames,
+			Evidence:    hit.evidence,
+		}
+
+		if hit.port != nil {
+			finding.Port = hit.port.ID
+			finding.Protocol = newPortKey(*hit.port).protocol
+			finding.Service = hit.port.Service.Name
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+func sortFindings(findings []Finding) {
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		return cmp.Or(
+			cmp.Compare(SeverityRank(b.Severity), SeverityRank(a.Severity)),
+			strings.Compare(a.Host, b.Host),
+			cmp.Compare(a.Port, b.Port),
+		)
+	})
+}
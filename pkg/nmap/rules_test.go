@@ -0,0 +1,133 @@
+package nmap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+func rulesTestRun() *nmap.Run {
+	open := nmap.State{State: "open"}
+	return &nmap.Run{Hosts: []nmap.Host{
+		{
+			Status:    nmap.Status{State: "up"},
+			Addresses: []nmap.Address{{Addr: "10.0.0.1", AddrType: "ipv4"}},
+			HostScripts: []nmap.Script{
+				{ID: "smb-security-mode", Output: "\n  account_used: guest\n  message_signing: disabled (dangerous, but default)"},
+			},
+			Ports: []nmap.Port{
+				{ID: 21, Protocol: "tcp", State: open, Service: nmap.Service{Name: "ftp"}, Scripts: []nmap.Script{
+					{ID: "ftp-anon", Output: "Anonymous FTP login allowed (FTP code 230)"},
+				}},
+				{ID: 23, Protocol: "tcp", State: nmap.State{State: "closed"}, Service: nmap.Service{Name: "telnet"}},
+				{ID: 445, Protocol: "tcp", State: open, Service: nmap.Service{Name: "microsoft-ds"}},
+			},
+		},
+		{
+			Status:    nmap.Status{State: "up"},
+			Addresses: []nmap.Address{{Addr: "8.8.8.8", AddrType: "ipv4"}},
+			Ports: []nmap.Port{
+				{ID: 445, Protocol: "tcp", State: open, Service: nmap.Service{Name: "microsoft-ds"}},
+				{ID: 6380, Protocol: "tcp", State: open, Service: nmap.Service{Name: "redis"}},
+				{ID: 2323, Protocol: "tcp", State: open, Service: nmap.Service{Name: "telnet"}},
+			},
+		},
+	}}
+}
+
+func TestGetFindings(t *testing.T) {
+	custom := filepath.Join(t.TempDir(), "rules.yaml")
+	err := os.WriteFile(custom, []byte(`rules:
+  - id: ftp-open
+    disabled: true
+  - id: telnet-open
+    title: Telnet
+    severity: critical
+    match:
+      services: [telnet]
+  - id: lab-smb
+    title: SMB in the lab
+    severity: low
+    match:
+      cidrs: [10.0.0.0/24]
+      ports: [445]
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		paths []string
+		want  []string
+	}{
+		{
+			name: "built in rules",
+			want: []string{
+				"smb-public 8.8.8.8:445",
+				"ftp-anonymous 10.0.0.1:21",
+				"telnet-open 8.8.8.8:2323",
+				"database-public 8.8.8.8:6380",
+				"smb-signing-disabled 10.0.0.1:0",
+				"ftp-open 10.0.0.1:21",
+			},
+		},
+		{
+			name:  "custom rules",
+			paths: []string{custom},
+			want: []string{
+				"smb-public 8.8.8.8:445",
+				"telnet-open 8.8.8.8:2323",
+				"ftp-anonymous 10.0.0.1:21",
+				"database-public 8.8.8.8:6380",
+				"smb-signing-disabled 10.0.0.1:0",
+				"lab-smb 10.0.0.1:445",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := LoadRules(true, tt.paths...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, finding := range NewNmapView(rulesTestRun()).GetFindings(rules, 0) {
+				got = append(got, fmt.Sprintf("%s %s:%d", finding.RuleID, finding.Host, finding.Port))
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GetFindings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRulesInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+	}{
+		{name: "unknown severity", rules: "rules:\n  - id: x\n    severity: urgent\n"},
+		{name: "bad regex", rules: "rules:\n  - id: x\n    severity: low\n    match:\n      script_output: \"(\"\n"},
+		{name: "bad cidr", rules: "rules:\n  - id: x\n    severity: low\n    match:\n      cidrs: [10.0.0.0/33]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rules.yaml")
+			err := os.WriteFile(path, []byte(tt.rules), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := LoadRules(false, path); err == nil {
+				t.Error("LoadRules() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package nmap
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+// DefaultSchemes maps nmap service names to URL schemes. Services that are
+// not listed use their service name as the scheme.
+var DefaultSchemes = map[string]string{
+	"http":          "http",
+	"http-alt":      "http",
+	"http-proxy":    "http",
+	"http-mgmt":     "http",
+	"https":         "https",
+	"https-alt":     "https",
+	"ssl/http":      "https",
+	"ssh":           "ssh",
+	"ftp":           "ftp",
+	"ftps":          "ftps",
+	"ms-wbt-server": "rdp",
+	"microsoft-ds":  "smb",
+	"netbios-ssn":   "smb",
+	"ldap":          "ldap",
+	"ldaps":         "ldaps",
+	"ldapssl":       "ldaps",
+}
+
+// tlsSchemes maps schemes to their TLS variant, used when nmap found the
+// service behind TLS.
+var tlsSchemes = map[string]string{
+	"http": "https",
+	"ldap": "ldaps",
+	"ftp":  "ftps",
+	"imap": "imaps",
+	"pop3": "pop3s",
+	"smtp": "smtps",
+}
+
+// defaultPortSchemes are always used on their ports, whatever service nmap
+// named there, unless the service has a scheme set with SetSchemes.
+var defaultPortSchemes = map[uint16]string{
+	80:  "http",
+	443: "https",
+}
+
+// defaultSchemePorts are left out of URLs.
+var defaultSchemePorts = map[string]uint16{
+	"http":  80,
+	"https": 443,
+}
+
+var httpProtocolRe = regexp.MustCompile(`^https?`)
+
+// SetSchemes overrides the URL scheme used for the given service names.
+func (v *View) SetSchemes(schemes map[string]string) {
+	for service, scheme := range schemes {
+		v.schemes[strings.ToLower(service)] = scheme
+		v.customSchemes[strings.ToLower(service)] = scheme
+	}
+}
+
+// serviceScheme looks up the scheme of a service name, with or without its
+// "ssl/" prefix.
+func serviceScheme(schemes map[string]string, name string) (string, bool) {
+	scheme, ok := schemes[name]
+	if !ok {
+		scheme, ok = schemes[strings.TrimPrefix(name, "ssl/")]
+	}
+	return scheme, ok
+}
+
+// urlScheme returns the URL scheme for the port, or an empty string if it
+// can't be determined.
+func (v *View) urlScheme(port *nmap.Port) string {
+	name := strings.ToLower(port.Service.Name)
+
+	scheme, ok := serviceScheme(v.customSchemes, name)
+	if !ok {
+		if portScheme, isDefaultPort := defaultPortSchemes[port.ID]; isDefaultPort {
+			return portScheme
+		}
+
+		scheme, ok = serviceScheme(v.schemes, name)
+	}
+
+	if !ok {
+		name = strings.TrimPrefix(name, "ssl/")
+		if httpProtocolRe.MatchString(name) {
+			scheme = httpProtocolRe.FindString(name)
+		} else if name != "unknown" {
+			scheme = name
+		}
+	}
+
+	if isTLS(port) {
+		if tlsScheme, ok := tlsSchemes[scheme]; ok {
+			scheme = tlsScheme
+		}
+	}
+	return scheme
+}
+
+// isTLS reports whether nmap found the service behind TLS.
+func isTLS(port *nmap.Port) bool {
+	if port.Service.Tunnel == "ssl" || strings.HasPrefix(port.Service.Name, "ssl/") {
+		return true
+	}
+
+	for _, script := range port.Scripts {
+		if script.ID == "ssl-cert" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package nmap
+
+import (
+	"testing"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+func TestViewURLScheme(t *testing.T) {
+	tests := []struct {
+		name    string
+		port    nmap.Port
+		schemes map[string]string
+		want    string
+	}{
+		{
+			name: "ssl tunnel on 8443",
+			port: nmap.Port{ID: 8443, Service: nmap.Service{Name: "http", Tunnel: "ssl"}},
+			want: "https",
+		},
+		{
+			name: "ssl/http service name",
+			port: nmap.Port{ID: 8443, Service: nmap.Service{Name: "ssl/http"}},
+			want: "https",
+		},
+		{
+			name: "https-alt",
+			port: nmap.Port{ID: 8443, Service: nmap.Service{Name: "https-alt"}},
+			want: "https",
+		},
+		{
+			name: "ssl-cert script",
+			port: nmap.Port{ID: 8080, Service: nmap.Service{Name: "http-proxy"}, Scripts: []nmap.Script{{ID: "ssl-cert"}}},
+			want: "https",
+		},
+		{
+			name: "ldap over tls",
+			port: nmap.Port{ID: 636, Service: nmap.Service{Name: "ldap", Tunnel: "ssl"}},
+			want: "ldaps",
+		},
+		{
+			name: "rdp",
+			port: nmap.Port{ID: 3389, Service: nmap.Service{Name: "ms-wbt-server"}},
+			want: "rdp",
+		},
+		{
+			name: "smb",
+			port: nmap.Port{ID: 445, Service: nmap.Service{Name: "microsoft-ds"}},
+			want: "smb",
+		},
+		{
+			name: "unnamed service on 443",
+			port: nmap.Port{ID: 443},
+			want: "https",
+		},
+		{
+			name: "plain http on 443",
+			port: nmap.Port{ID: 443, Service: nmap.Service{Name: "http"}},
+			want: "https",
+		},
+		{
+			name: "https on 80",
+			port: nmap.Port{ID: 80, Service: nmap.Service{Name: "https"}},
+			want: "http",
+		},
+		{
+			name:    "custom scheme on 443",
+			port:    nmap.Port{ID: 443, Service: nmap.Service{Name: "http"}},
+			schemes: map[string]string{"http": "http"},
+			want:    "http",
+		},
+		{
+			name: "unknown service",
+			port: nmap.Port{ID: 9999, Service: nmap.Service{Name: "unknown"}},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewNmapView(&nmap.Run{})
+			v.SetSchemes(tt.schemes)
+			if got := v.urlScheme(&tt.port); got != tt.want {
+				t.Errorf("urlScheme() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
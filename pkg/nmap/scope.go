@@ -0,0 +1,168 @@
+package nmap
+
+import (
+	"bufio"
+	"fmt"
+	"net/netip"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+// maxScopeAddresses limits how many addresses a scope may expand to.
+const maxScopeAddresses = 1 << 20
+
+var octetRangeRe = regexp.MustCompile(`^(\d+\.\d+\.\d+\.)(\d+)-(\d+)$`)
+
+// addrRange is an inclusive range of IP addresses.
+type addrRange struct {
+	first netip.Addr
+	last  netip.Addr
+}
+
+func (r addrRange) contains(addr netip.Addr) bool {
+	return r.first.Compare(addr) <= 0 && addr.Compare(r.last) <= 0
+}
+
+// parseAddrRange parses an IP, a CIDR, or a range in either the
+// 10.0.0.1-50 or 10.0.0.1-10.0.0.50 format.
+func parseAddrRange(spec string) (addrRange, bool) {
+	if addr, err := netip.ParseAddr(spec); err == nil {
+		return addrRange{first: addr, last: addr}, true
+	}
+
+	if prefix, err := netip.ParsePrefix(spec); err == nil {
+		prefix = prefix.Masked()
+		return addrRange{first: prefix.Addr(), last: lastAddr(prefix)}, true
+	}
+
+	if match := octetRangeRe.FindStringSubmatch(spec); match != nil {
+		spec = fmt.Sprintf("%s%s-%s%s", match[1], match[2], match[1], match[3])
+	}
+
+	firstStr, lastStr, ok := strings.Cut(spec, "-")
+	if !ok {
+		return addrRange{}, false
+	}
+
+	first, err := netip.ParseAddr(firstStr)
+	if err != nil {
+		return addrRange{}, false
+	}
+
+	last, err := netip.ParseAddr(lastStr)
+	if err != nil || last.Less(first) {
+		return addrRange{}, false
+	}
+
+	return addrRange{first: first, last: last}, true
+}
+
+// lastAddr returns the last address of the prefix.
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	bits := prefix.Bits()
+	if prefix.Addr().Is4() {
+		bits += 96
+	}
+
+	addr := prefix.Addr().As16()
+	for i := bits; i < 128; i++ {
+		addr[i/8] |= 1 << (7 - i%8)
+	}
+
+	last := netip.AddrFrom16(addr)
+	if prefix.Addr().Is4() {
+		last = last.Unmap()
+	}
+	return last
+}
+
+// Scope is the list of addresses and hostnames that are in scope.
+type Scope struct {
+	ranges    []addrRange
+	hostnames []string
+}
+
+// ReadScope reads a scope file with one IP, CIDR, IP range or hostname per
+// line. Empty lines and lines starting with # are ignored.
+func ReadScope(path string) (*Scope, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scope := &Scope{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		r, ok := parseAddrRange(line)
+		if ok {
+			scope.ranges = append(scope.ranges, r)
+		} else {
+			scope.hostnames = append(scope.hostnames, strings.ToLower(line))
+		}
+	}
+	return scope, scanner.Err()
+}
+
+// Addresses returns every address in scope.
+func (s *Scope) Addresses() ([]netip.Addr, error) {
+	var addrs []netip.Addr
+	seen := map[netip.Addr]bool{}
+	for _, r := range s.ranges {
+		for addr := r.first; addr.IsValid() && addr.Compare(r.last) <= 0; addr = addr.Next() {
+			if seen[addr] {
+				continue
+			}
+
+			seen[addr] = true
+			addrs = append(addrs, addr)
+			if len(addrs) > maxScopeAddresses {
+				return nil, fmt.Errorf("scope contains more than %d addresses", maxScopeAddresses)
+			}
+		}
+	}
+	return addrs, nil
+}
+
+// Hostnames returns the hostnames in scope.
+func (s *Scope) Hostnames() []string {
+	return s.hostnames
+}
+
+// ContainsAddr reports whether the address is in scope.
+func (s *Scope) ContainsAddr(addr netip.Addr) bool {
+	for _, r := range s.ranges {
+		if r.contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsHost reports whether any address or hostname of the host is in
+// scope.
+func (s *Scope) ContainsHost(h *nmap.Host) bool {
+	for _, a := range h.Addresses {
+		addr, err := netip.ParseAddr(a.Addr)
+		if err == nil && s.ContainsAddr(addr) {
+			return true
+		}
+	}
+
+	for _, hostname := range h.Hostnames {
+		for _, scopeHostname := range s.hostnames {
+			if strings.EqualFold(hostname.Name, scopeHostname) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,393 @@
+package nmap
+
+import (
+	"cmp"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Statuses of a target in a scope coverage report.
+const (
+	StatusNotScanned = "not scanned"
+	StatusUnknown    = "unknown"
+	StatusSkipped    = "skipped"
+	StatusDown       = "down"
+	StatusUp         = "up"
+)
+
+// ScopeCoverage is how well a single target was scanned.
+type ScopeCoverage struct {
+	Target string   `json:"target"`
+	Status string   `json:"status"`
+	Reason string   `json:"reason,omitempty"`
+	TCP    string   `json:"tcp"`
+	UDP    string   `json:"udp"`
+	Scans  []string `json:"scans"`
+
+	coverage Coverage
+}
+
+func (c *ScopeCoverage) addScan(path string) {
+	if !slices.Contains(c.Scans, path) {
+		c.Scans = append(c.Scans, path)
+	}
+}
+
+// setStatus updates the status unless the target was already seen in a
+// better state. Up beats down, which beats skipped, which beats unknown.
+func (c *ScopeCoverage) setStatus(status string, reason string) {
+	rank := []string{StatusNotScanned, StatusUnknown, StatusSkipped, StatusDown, StatusUp}
+	if slices.Index(rank, status) > slices.Index(rank, c.Status) {
+		c.Status = status
+		c.Reason = reason
+	}
+}
+
+// CoverageReport shows which in scope targets were scanned and on which
+// ports, along with the hosts that were scanned but are out of scope.
+type CoverageReport struct {
+	InScope    []ScopeCoverage `json:"in_scope"`
+	OutOfScope []ScopeCoverage `json:"out_of_scope"`
+	Summary    map[string]int  `json:"summary"`
+}
+
+// nmapValueOptions are the nmap options that take the next argument as
+// their value, so it is not mistaken for a target. Options like -d and -v
+// take their level attached, as in -d3, so they are not listed.
+var nmapValueOptions = []string{
+	"b", "D", "e", "g", "iL", "iR", "oA", "oG", "oM", "oN", "oS", "oX", "p", "S", "sI",
+	"data", "data-hex", "data-length", "data-string", "datadir", "dns-servers",
+	"exclude", "exclude-ports", "excludefile", "host-timeout", "initial-rtt-timeout",
+	"ip-options", "max-hostgroup", "max-os-tries", "max-parallelism", "max-rate",
+	"max-retries", "max-rtt-timeout", "max-scan-delay", "min-hostgroup",
+	"min-parallelism", "min-rate", "min-rtt-timeout", "mtu", "port-ratio", "proxies",
+	"proxy", "resume", "scan-delay", "scanflags", "script", "script-args",
+	"script-args-file", "script-help", "script-timeout", "servicedb", "source-port",
+	"spoof-mac", "stats-every", "stylesheet", "top-ports", "ttl", "version-intensity",
+	"versiondb",
+}
+
+// octetPattern is an nmap IPv4 target with octet ranges, like 10.0.1,3.0 or
+// 192.168.*.1-10. Each octet is a list of inclusive ranges.
+type octetPattern [4][][2]uint8
+
+func parseOctetPattern(spec string) (octetPattern, bool) {
+	var pattern octetPattern
+	octets := strings.Split(spec, ".")
+	if len(octets) != 4 {
+		return pattern, false
+	}
+
+	for i, octet := range octets {
+		for _, part := range strings.Split(octet, ",") {
+			if part == "*" {
+				part = "0-255"
+			}
+
+			lowStr, highStr, isRange := strings.Cut(part, "-")
+			if !isRange {
+				highStr = lowStr
+			} else {
+				lowStr = cmp.Or(lowStr, "0")
+				highStr = cmp.Or(highStr, "255")
+			}
+
+			low, err := strconv.ParseUint(lowStr, 10, 8)
+			if err != nil {
+				return pattern, false
+			}
+
+			high, err := strconv.ParseUint(highStr, 10, 8)
+			if err != nil || high < low {
+				return pattern, false
+			}
+			pattern[i] = append(pattern[i], [2]uint8{uint8(low), uint8(high)})
+		}
+	}
+	return pattern, true
+}
+
+func (p octetPattern) contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.Is4() {
+		return false
+	}
+
+	octets := addr.As4()
+	for i, octet := range octets {
+		inRange := slices.ContainsFunc(p[i], func(r [2]uint8) bool {
+			return r[0] <= octet && octet <= r[1]
+		})
+		if !inRange {
+			return false
+		}
+	}
+	return true
+}
+
+// targetList is a list of nmap target specifications.
+type targetList struct {
+	ranges   []addrRange
+	patterns []octetPattern
+	names    []string
+}
+
+func (l *targetList) add(spec string) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return
+	}
+
+	if r, ok := parseAddrRange(spec); ok {
+		l.ranges = append(l.ranges, r)
+	} else if pattern, ok := parseOctetPattern(spec); ok {
+		l.patterns = append(l.patterns, pattern)
+	} else {
+		l.names = append(l.names, strings.ToLower(spec))
+	}
+}
+
+func (l *targetList) containsAddr(addr netip.Addr) bool {
+	return slices.ContainsFunc(l.ranges, func(r addrRange) bool { return r.contains(addr) }) ||
+		slices.ContainsFunc(l.patterns, func(p octetPattern) bool { return p.contains(addr) })
+}
+
+func (l *targetList) containsName(name string) bool {
+	return slices.Contains(l.names, name)
+}
+
+// scanTargets are the targets given to nmap on the command line of a run,
+// minus the excluded ones. When the targets can't all be known, like with
+// random targets or an input list that can't be read, unknown says why.
+type scanTargets struct {
+	include targetList
+	exclude targetList
+	unknown string
+}
+
+// targeted reports whether the scan was told to scan a target, was not, or
+// may have been if its targets are unknown.
+func (t *scanTargets) targeted(match func(*targetList) bool) (bool, bool) {
+	if match(&t.exclude) {
+		return false, false
+	}
+	return match(&t.include), t.unknown != ""
+}
+
+// readTargetFile reads the targets in an nmap input or exclude list. Relative
+// paths are also looked up next to the scan.
+func readTargetFile(path string, dir string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !filepath.IsAbs(path) {
+		data, err = os.ReadFile(filepath.Join(dir, path))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var specs []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		specs = append(specs, strings.Fields(line)...)
+	}
+	return specs, nil
+}
+
+// parseScanTargets parses the targets out of the nmap command line of a
+// scan saved in dir. The values of options are skipped, --exclude and
+// --excludefile are honoured and -iL lists are read when they still exist.
+func parseScanTargets(args string, dir string) scanTargets {
+	var targets scanTargets
+	fields := strings.Fields(args)
+
+	// the first argument is the nmap binary
+	for i := 1; i < len(fields); i++ {
+		arg := fields[i]
+		if !strings.HasPrefix(arg, "-") {
+			targets.include.add(arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !slices.Contains(nmapValueOptions, name) {
+			continue
+		}
+
+		if !hasValue {
+			if i+1 == len(fields) {
+				break
+			}
+			i++
+			value = fields[i]
+		}
+
+		switch name {
+		case "exclude":
+			for _, spec := range strings.Split(value, ",") {
+				targets.exclude.add(spec)
+			}
+		case "excludefile", "iL":
+			specs, err := readTargetFile(value, dir)
+			if err != nil {
+				targets.unknown = fmt.Sprintf("targets in -%s %s are unknown", name, value)
+				continue
+			}
+
+			list := &targets.include
+			if name == "excludefile" {
+				list = &targets.exclude
+			}
+
+			for _, spec := range specs {
+				list.add(spec)
+			}
+		case "iR":
+			targets.unknown = "random targets (-iR)"
+		}
+	}
+	return targets
+}
+
+// NewCoverageReport compares the scans to the scope. Targets that were on an
+// nmap command line but have no host entry are reported as down, since nmap
+// leaves out down hosts unless run verbosely. Targets that may have been in
+// an input list that can no longer be read are reported as unknown.
+func NewCoverageReport(scope *Scope, scans []*Scan) (*CoverageReport, error) {
+	addrs, err := scope.Addresses()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]*ScopeCoverage{}
+	get := func(target string) *ScopeCoverage {
+		c, ok := seen[target]
+		if !ok {
+			c = &ScopeCoverage{Target: target, Status: StatusNotScanned, coverage: Coverage{}}
+			seen[target] = c
+		}
+		return c
+	}
+
+	var outOfScope []string
+	for _, scan := range scans {
+		for _, h := range scan.Run.Hosts {
+			var targets []string
+			for _, a := range h.Addresses {
+				addr, err := netip.ParseAddr(a.Addr)
+				if err == nil {
+					targets = append(targets, addr.String())
+				}
+			}
+			for _, hostname := range h.Hostnames {
+				targets = append(targets, strings.ToLower(hostname.Name))
+			}
+
+			for _, target := range targets {
+				c := get(target)
+				c.addScan(scan.Path)
+				if h.Status.State == "up" {
+					c.setStatus(StatusUp, h.Status.Reason)
+					c.coverage.Add(scan.Coverage)
+				} else {
+					c.setStatus(StatusDown, h.Status.Reason)
+				}
+			}
+
+			if h.Status.State == "up" && !scope.ContainsHost(&h) && len(targets) > 0 && !slices.Contains(outOfScope, targets[0]) {
+				outOfScope = append(outOfScope, targets[0])
+			}
+		}
+
+		for _, target := range scan.Run.Targets {
+			c := get(strings.ToLower(target.Specification))
+			c.addScan(scan.Path)
+			c.setStatus(StatusSkipped, fmt.Sprintf("%s: %s", target.Status, target.Reason))
+		}
+	}
+
+	var targeted []scanTargets
+	for _, scan := range scans {
+		targeted = append(targeted, parseScanTargets(scan.Run.Args, filepath.Dir(scan.Path)))
+	}
+
+	report := &CoverageReport{Summary: map[string]int{}}
+	addEntry := func(target string, match func(*targetList) bool) {
+		c, ok := seen[target]
+		if !ok {
+			c = &ScopeCoverage{Target: target, Status: StatusNotScanned}
+		}
+
+		if c.Status == StatusNotScanned {
+			for i, t := range targeted {
+				included, unknown := t.targeted(match)
+				if included {
+					c.setStatus(StatusDown, "no host entry")
+					c.addScan(scans[i].Path)
+				} else if unknown {
+					c.setStatus(StatusUnknown, t.unknown)
+					c.addScan(scans[i].Path)
+				}
+			}
+		}
+
+		c.TCP = describeCoverage(c.coverage[ProtocolTCP])
+		c.UDP = describeCoverage(c.coverage[ProtocolUDP])
+		report.InScope = append(report.InScope, *c)
+
+		report.Summary[c.Status]++
+		if c.Status == StatusUp {
+			if len(c.coverage[ProtocolUDP]) > 0 {
+				report.Summary["tcp and udp"]++
+			} else {
+				report.Summary["tcp only"]++
+			}
+
+			if c.coverage[ProtocolTCP].IsFullRange() {
+				report.Summary["full tcp range"]++
+			}
+		}
+	}
+
+	for _, addr := range addrs {
+		addEntry(addr.String(), func(l *targetList) bool {
+			return l.containsAddr(addr)
+		})
+	}
+
+	for _, hostname := range scope.Hostnames() {
+		addEntry(hostname, func(l *targetList) bool {
+			return l.containsName(hostname)
+		})
+	}
+
+	for _, target := range outOfScope {
+		c := seen[target]
+		c.TCP = describeCoverage(c.coverage[ProtocolTCP])
+		c.UDP = describeCoverage(c.coverage[ProtocolUDP])
+		report.OutOfScope = append(report.OutOfScope, *c)
+	}
+	report.Summary["out of scope"] = len(report.OutOfScope)
+
+	return report, nil
+}
+
+// describeCoverage summarizes the scanned ports of a single protocol.
+func describeCoverage(ranges PortRanges) string {
+	count := ranges.Count()
+	switch {
+	case count == 0:
+		return "none"
+	case ranges.IsFullRange():
+		return "full"
+	case count == 1000:
+		// nmap scans the top 1000 ports unless told otherwise
+		return "top-1000"
+	}
+	return fmt.Sprintf("%d ports", count)
+}
@@ -0,0 +1,175 @@
+package nmap
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseAddrRange(t *testing.T) {
+	tests := []struct {
+		spec      string
+		wantFirst string
+		wantLast  string
+		wantOK    bool
+	}{
+		{spec: "10.0.0.1", wantFirst: "10.0.0.1", wantLast: "10.0.0.1", wantOK: true},
+		{spec: "10.0.0.7/29", wantFirst: "10.0.0.0", wantLast: "10.0.0.7", wantOK: true},
+		{spec: "10.0.0.1-50", wantFirst: "10.0.0.1", wantLast: "10.0.0.50", wantOK: true},
+		{spec: "10.0.0.1-10.0.1.1", wantFirst: "10.0.0.1", wantLast: "10.0.1.1", wantOK: true},
+		{spec: "2001:db8::/126", wantFirst: "2001:db8::", wantLast: "2001:db8::3", wantOK: true},
+		{spec: "example.com", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, ok := parseAddrRange(tt.spec)
+			if ok != tt.wantOK {
+				t.Fatalf("parseAddrRange() ok = %v, want %v", ok, tt.wantOK)
+			}
+
+			if ok && (got.first.String() != tt.wantFirst || got.last.String() != tt.wantLast) {
+				t.Errorf("parseAddrRange() = %v-%v, want %v-%v", got.first, got.last, tt.wantFirst, tt.wantLast)
+			}
+		})
+	}
+}
+
+func TestNewCoverageReport(t *testing.T) {
+	scopePath := filepath.Join(t.TempDir(), "scope.txt")
+	err := os.WriteFile(scopePath, []byte("# scope\n10.0.0.1\n10.0.0.2\n10.0.0.3\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scope, err := ReadScope(scopePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hosts := hostXML("10.0.0.1", "up", 1000, openPort(22)) +
+		hostXML("10.0.0.2", "down", 1000, "") +
+		hostXML("10.0.0.8", "up", 1000, openPort(22))
+	scans, err := ReadScans([]string{writeRun(t, "scan.xml", 1000, "1-1000", hosts)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := NewCoverageReport(scope, scans)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"10.0.0.1": StatusUp + " top-1000",
+		"10.0.0.2": StatusDown + " none",
+		"10.0.0.3": StatusNotScanned + " none",
+	}
+	for _, entry := range report.InScope {
+		if got := entry.Status + " " + entry.TCP; got != want[entry.Target] {
+			t.Errorf("%s = %q, want %q", entry.Target, got, want[entry.Target])
+		}
+	}
+
+	if len(report.OutOfScope) != 1 || report.OutOfScope[0].Target != "10.0.0.8" {
+		t.Errorf("OutOfScope = %v, want 10.0.0.8", report.OutOfScope)
+	}
+
+	if !scope.ContainsAddr(netip.MustParseAddr("10.0.0.3")) {
+		t.Errorf("ContainsAddr(10.0.0.3) = false, want true")
+	}
+}
+
+func TestParseScanTargets(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "targets.txt"), []byte("# hosts\n10.0.2.1 10.0.2.2\nfiles.example.com\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "exclude.txt"), []byte("10.0.2.2\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		args        string
+		wantAddrs   []string
+		wantNames   []string
+		wantUnknown bool
+	}{
+		{
+			name:      "option values are not targets",
+			args:      "/usr/bin/nmap -sS -p 22,80 -oX out.xml --script http-title --min-rate=1000 -T4 10.0.0.1 web.example.com",
+			wantAddrs: []string{"10.0.0.1"},
+			wantNames: []string{"web.example.com"},
+		},
+		{
+			name:      "debug level before a target",
+			args:      "nmap -sS -d 10.0.0.1 -d3 10.0.0.3",
+			wantAddrs: []string{"10.0.0.1", "10.0.0.3"},
+		},
+		{
+			name:      "excluded targets",
+			args:      "nmap --exclude 10.0.0.2,skip.example.com 10.0.0.0/29 skip.example.com",
+			wantAddrs: []string{"10.0.0.1", "10.0.0.3"},
+		},
+		{
+			name:      "octet ranges",
+			args:      "nmap 10.0.1,3.0 10.0.4.1-5 10.0.5.*",
+			wantAddrs: []string{"10.0.1.0", "10.0.3.0", "10.0.4.5", "10.0.5.7"},
+		},
+		{
+			name:      "input and exclude lists",
+			args:      "nmap -iL targets.txt --excludefile " + filepath.Join(dir, "exclude.txt"),
+			wantAddrs: []string{"10.0.2.1"},
+			wantNames: []string{"files.example.com"},
+		},
+		{
+			name:        "missing input list",
+			args:        "nmap -iL gone.txt",
+			wantUnknown: true,
+		},
+	}
+
+	candidates := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.1.0", "10.0.2.0", "10.0.2.1", "10.0.2.2", "10.0.3.0", "10.0.4.5", "10.0.4.6", "10.0.5.7", "out.xml"}
+	names := []string{"web.example.com", "skip.example.com", "files.example.com", "http-title", "out.xml"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets := parseScanTargets(tt.args, dir)
+
+			var gotAddrs []string
+			for _, candidate := range candidates {
+				addr, err := netip.ParseAddr(candidate)
+				if err != nil {
+					continue
+				}
+
+				if included, _ := targets.targeted(func(l *targetList) bool { return l.containsAddr(addr) }); included {
+					gotAddrs = append(gotAddrs, candidate)
+				}
+			}
+
+			var gotNames []string
+			for _, name := range names {
+				if included, _ := targets.targeted(func(l *targetList) bool { return l.containsName(name) }); included {
+					gotNames = append(gotNames, name)
+				}
+			}
+
+			if !slices.Equal(gotAddrs, tt.wantAddrs) {
+				t.Errorf("targeted addresses = %v, want %v", gotAddrs, tt.wantAddrs)
+			}
+
+			if !slices.Equal(gotNames, tt.wantNames) {
+				t.Errorf("targeted names = %v, want %v", gotNames, tt.wantNames)
+			}
+
+			if unknown := targets.unknown != ""; unknown != tt.wantUnknown {
+				t.Errorf("unknown = %q, want unknown %v", targets.unknown, tt.wantUnknown)
+			}
+		})
+	}
+}
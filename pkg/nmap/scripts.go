@@ -0,0 +1,548 @@
+package nmap
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"html"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+// ScriptParser turns the output of an NSE script into a typed value.
+type ScriptParser func(script nmap.Script) (any, error)
+
+var scriptParsers = map[string]ScriptParser{
+	"ssl-cert":           func(s nmap.Script) (any, error) { return ParseSSLCert(s) },
+	"http-title":         func(s nmap.Script) (any, error) { return ParseHTTPTitle(s), nil },
+	"http-server-header": func(s nmap.Script) (any, error) { return ParseHTTPServerHeader(s), nil },
+	"smb-os-discovery":   func(s nmap.Script) (any, error) { return ParseSMBOSDiscovery(s), nil },
+	"smb-security-mode":  func(s nmap.Script) (any, error) { return ParseSMBSecurityMode(s), nil },
+	"ssh-hostkey":        func(s nmap.Script) (any, error) { return ParseSSHHostKeys(s) },
+	"rdp-ntlm-info":      func(s nmap.Script) (any, error) { return ParseRDPNTLMInfo(s), nil },
+	"vulners":            func(s nmap.Script) (any, error) { return ParseVulners(s) },
+	"vulscan":            func(s nmap.Script) (any, error) { return ParseVulscan(s), nil },
+}
+
+// RegisterScriptParser sets the parser used for a script ID, replacing any
+// existing one.
+func RegisterScriptParser(id string, parser ScriptParser) {
+	scriptParsers[id] = parser
+}
+
+// ParseScript returns the typed value of the script. Nil is returned for
+// scripts without a parser and parsers without a result, like a nil
+// certificate for output that can't be parsed.
+func ParseScript(script nmap.Script) (any, error) {
+	parser, ok := scriptParsers[script.ID]
+	if !ok {
+		return nil, nil
+	}
+
+	parsed, err := parser(script)
+	if value := reflect.ValueOf(parsed); parsed != nil && value.Kind() == reflect.Pointer && value.IsNil() {
+		return nil, err
+	}
+	return parsed, err
+}
+
+// findScript returns the script with the given ID.
+func findScript(scripts []nmap.Script, id string) (nmap.Script, bool) {
+	i := slices.IndexFunc(scripts, func(s nmap.Script) bool { return s.ID == id })
+	if i == -1 {
+		return nmap.Script{}, false
+	}
+	return scripts[i], true
+}
+
+// findTable returns the table with the given key.
+func findTable(tables []nmap.Table, key string) (nmap.Table, bool) {
+	i := slices.IndexFunc(tables, func(t nmap.Table) bool { return t.Key == key })
+	if i == -1 {
+		return nmap.Table{}, false
+	}
+	return tables[i], true
+}
+
+// tableMap returns the keyed elements of a table.
+func tableMap(table nmap.Table) map[string]string {
+	values := map[string]string{}
+	for _, elem := range table.Elements {
+		if elem.Key != "" {
+			values[elem.Key] = html.UnescapeString(elem.Value)
+		}
+	}
+	return values
+}
+
+// outputFields returns the "key: value" lines of a script's text output.
+func outputFields(output string) map[string]string {
+	fields := map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if ok {
+			fields[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return fields
+}
+
+var scriptTimeLayouts = []string{
+	"2006-01-02T15:04:05",
+	time.RFC3339,
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05",
+}
+
+// parseScriptTime parses the timestamps NSE scripts output, which are UTC
+// unless they say otherwise.
+func parseScriptTime(value string) (time.Time, error) {
+	for _, layout := range scriptTimeLayouts {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown time format %q", value)
+}
+
+// SSLCert is the certificate reported by the ssl-cert script.
+type SSLCert struct {
+	Subject            map[string]string `json:"subject"`
+	Issuer             map[string]string `json:"issuer"`
+	SANs               []string          `json:"sans"`
+	KeyType            string            `json:"key_type"`
+	KeyBits            int               `json:"key_bits"`
+	SignatureAlgorithm string            `json:"signature_algorithm"`
+	NotBefore          time.Time         `json:"not_before"`
+	NotAfter           time.Time         `json:"not_after"`
+	MD5                string            `json:"md5"`
+	SHA1               string            `json:"sha1"`
+	SHA256             string            `json:"sha256"`
+	PEM                string            `json:"pem,omitempty"`
+}
+
+// CommonName returns the common name of the certificate subject.
+func (c *SSLCert) CommonName() string {
+	return c.Subject["commonName"]
+}
+
+// DNSNames returns the DNS names in the subject alternative names.
+func (c *SSLCert) DNSNames() []string {
+	var names []string
+	for _, san := range c.SANs {
+		name, ok := strings.CutPrefix(san, "DNS:")
+		if ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ParseSSLCert parses ssl-cert output, preferring the structured tables and
+// falling back to the text output of older nmap versions.
+func ParseSSLCert(script nmap.Script) (*SSLCert, error) {
+	cert := &SSLCert{
+		Subject: map[string]string{},
+		Issuer:  map[string]string{},
+	}
+	fields := outputFields(script.Output)
+	elements := tableMap(nmap.Table{Elements: script.Elements})
+
+	if subject, ok := findTable(script.Tables, "subject"); ok {
+		cert.Subject = tableMap(subject)
+	} else {
+		cert.Subject = parseDistinguishedName(fields["Subject"])
+	}
+
+	if issuer, ok := findTable(script.Tables, "issuer"); ok {
+		cert.Issuer = tableMap(issuer)
+	} else {
+		cert.Issuer = parseDistinguishedName(fields["Issuer"])
+	}
+
+	sans := fields["Subject Alternative Name"]
+	if extensions, ok := findTable(script.Tables, "extensions"); ok {
+		for _, extension := range extensions.Tables {
+			values := tableMap(extension)
+			if values["name"] == "X509v3 Subject Alternative Name" {
+				sans = values["value"]
+			}
+		}
+	}
+	for _, san := range strings.Split(sans, ",") {
+		if san = strings.TrimSpace(san); san != "" {
+			cert.SANs = append(cert.SANs, san)
+		}
+	}
+
+	bits := fields["Public Key bits"]
+	cert.KeyType = fields["Public Key type"]
+	if pubkey, ok := findTable(script.Tables, "pubkey"); ok {
+		values := tableMap(pubkey)
+		cert.KeyType = values["type"]
+		bits = values["bits"]
+	}
+
+	if bits != "" {
+		var err error
+		cert.KeyBits, err = strconv.Atoi(bits)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key bits: %w", err)
+		}
+	}
+
+	cert.SignatureAlgorithm = cmp.Or(elements["sig_algo"], fields["Signature Algorithm"])
+
+	notBefore := fields["Not valid before"]
+	notAfter := fields["Not valid after"]
+	if validity, ok := findTable(script.Tables, "validity"); ok {
+		values := tableMap(validity)
+		notBefore = values["notBefore"]
+		notAfter = values["notAfter"]
+	}
+
+	var err error
+	if notBefore != "" {
+		cert.NotBefore, err = parseScriptTime(notBefore)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if notAfter != "" {
+		cert.NotAfter, err = parseScriptTime(notAfter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cert.MD5 = cmp.Or(elements["md5"], strings.ReplaceAll(fields["MD5"], " ", ""))
+	cert.SHA1 = cmp.Or(elements["sha1"], strings.ReplaceAll(fields["SHA-1"], " ", ""))
+	cert.SHA256 = elements["sha256"]
+	cert.PEM = elements["pem"]
+
+	// older nmap versions don't report a SHA-256 fingerprint
+	if cert.SHA256 == "" && cert.PEM != "" {
+		block, _ := pem.Decode([]byte(cert.PEM))
+		if block != nil && len(block.Bytes) > 0 {
+			sum := sha256.Sum256(block.Bytes)
+			cert.SHA256 = hex.EncodeToString(sum[:])
+		}
+	}
+
+	return cert, nil
+}
+
+// parseDistinguishedName parses the "commonName=a/organizationName=b"
+// subject and issuer format of the ssl-cert text output.
+func parseDistinguishedName(dn string) map[string]string {
+	names := map[string]string{}
+	for _, part := range strings.Split(dn, "/") {
+		key, value, ok := strings.Cut(part, "=")
+		if ok {
+			names[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return names
+}
+
+var redirectRe = regexp.MustCompile(`Did not follow redirect to (\S+)`)
+
+// HTTPTitle is the page title reported by the http-title script.
+type HTTPTitle struct {
+	Title       string `json:"title,omitempty"`
+	RedirectURL string `json:"redirect_url,omitempty"`
+}
+
+// ParseHTTPTitle parses http-title output.
+func ParseHTTPTitle(script nmap.Script) *HTTPTitle {
+	elements := tableMap(nmap.Table{Elements: script.Elements})
+	title := &HTTPTitle{
+		Title:       elements["title"],
+		RedirectURL: elements["redirect_url"],
+	}
+
+	if title.RedirectURL == "" {
+		match := redirectRe.FindStringSubmatch(script.Output)
+		if len(match) == 2 {
+			title.RedirectURL = match[1]
+		}
+	}
+
+	if title.Title == "" && title.RedirectURL == "" {
+		title.Title = strings.TrimSpace(script.Output)
+	}
+	return title
+}
+
+// HTTPServerHeader is the Server header reported by the http-server-header
+// script.
+type HTTPServerHeader struct {
+	Server string `json:"server"`
+}
+
+// ParseHTTPServerHeader parses http-server-header output.
+func ParseHTTPServerHeader(script nmap.Script) *HTTPServerHeader {
+	for _, elem := range script.Elements {
+		if elem.Key == "" {
+			return &HTTPServerHeader{Server: html.UnescapeString(elem.Value)}
+		}
+	}
+	return &HTTPServerHeader{Server: strings.TrimSpace(script.Output)}
+}
+
+// SMBOSDiscovery is the host information reported by the smb-os-discovery
+// script.
+type SMBOSDiscovery struct {
+	OS         string `json:"os"`
+	LANManager string `json:"lanmanager,omitempty"`
+	Server     string `json:"server,omitempty"`
+	Domain     string `json:"domain,omitempty"`
+	Workgroup  string `json:"workgroup,omitempty"`
+	FQDN       string `json:"fqdn,omitempty"`
+	DomainDNS  string `json:"domain_dns,omitempty"`
+	ForestDNS  string `json:"forest_dns,omitempty"`
+	CPE        string `json:"cpe,omitempty"`
+}
+
+// ParseSMBOSDiscovery parses smb-os-discovery output.
+func ParseSMBOSDiscovery(script nmap.Script) *SMBOSDiscovery {
+	elements := tableMap(nmap.Table{Elements: script.Elements})
+	fields := outputFields(script.Output)
+	return &SMBOSDiscovery{
+		OS:         cmp.Or(elements["os"], fields["OS"]),
+		LANManager: elements["lanmanager"],
+		Server:     cmp.Or(elements["server"], strings.TrimSuffix(fields["NetBIOS computer name"], `\x00`)),
+		Domain:     cmp.Or(elements["domain"], fields["Domain name"]),
+		Workgroup:  cmp.Or(elements["workgroup"], fields["Workgroup"]),
+		FQDN:       cmp.Or(elements["fqdn"], fields["FQDN"]),
+		DomainDNS:  elements["domain_dns"],
+		ForestDNS:  cmp.Or(elements["forest_dns"], fields["Forest name"]),
+		CPE:        elements["cpe"],
+	}
+}
+
+// SMBSecurityMode is the SMBv1 security configuration reported by the
+// smb-security-mode script.
+type SMBSecurityMode struct {
+	AccountUsed         string `json:"account_used,omitempty"`
+	AuthenticationLevel string `json:"authentication_level,omitempty"`
+	ChallengeResponse   string `json:"challenge_response,omitempty"`
+	MessageSigning      string `json:"message_signing,omitempty"`
+}
+
+// SigningRequired reports whether the server requires message signing.
+func (m *SMBSecurityMode) SigningRequired() bool {
+	return m.MessageSigning == "required"
+}
+
+// ParseSMBSecurityMode parses smb-security-mode output.
+func ParseSMBSecurityMode(script nmap.Script) *SMBSecurityMode {
+	elements := tableMap(nmap.Table{Elements: script.Elements})
+	fields := outputFields(script.Output)
+	return &SMBSecurityMode{
+		AccountUsed:         cmp.Or(elements["account_used"], fields["account_used"]),
+		AuthenticationLevel: cmp.Or(elements["authentication_level"], fields["authentication_level"]),
+		ChallengeResponse:   cmp.Or(elements["challenge_response"], fields["challenge_response"]),
+		MessageSigning:      cmp.Or(elements["message_signing"], fields["message_signing"]),
+	}
+}
+
+// SSHHostKey is a host key reported by the ssh-hostkey script.
+type SSHHostKey struct {
+	Type        string `json:"type"`
+	Bits        int    `json:"bits"`
+	Fingerprint string `json:"fingerprint"`
+	Key         string `json:"key,omitempty"`
+}
+
+var sshHostKeyRe = regexp.MustCompile(`(?m)^\s*(\d+) ([0-9a-f:]+) \((\w+)\)`)
+
+// ParseSSHHostKeys parses ssh-hostkey output.
+func ParseSSHHostKeys(script nmap.Script) ([]SSHHostKey, error) {
+	var keys []SSHHostKey
+	for _, table := range script.Tables {
+		values := tableMap(table)
+		key := SSHHostKey{
+			Type:        values["type"],
+			Fingerprint: values["fingerprint"],
+			Key:         values["key"],
+		}
+
+		if values["bits"] != "" {
+			var err error
+			key.Bits, err = strconv.Atoi(values["bits"])
+			if err != nil {
+				return nil, fmt.Errorf("invalid host key bits: %w", err)
+			}
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) > 0 {
+		return keys, nil
+	}
+
+	for _, match := range sshHostKeyRe.FindAllStringSubmatch(script.Output, -1) {
+		bits, _ := strconv.Atoi(match[1])
+		keys = append(keys, SSHHostKey{
+			Type:        strings.ToLower(match[3]),
+			Bits:        bits,
+			Fingerprint: strings.ReplaceAll(match[2], ":", ""),
+		})
+	}
+	return keys, nil
+}
+
+// RDPNTLMInfo is the NTLM information reported by the rdp-ntlm-info script.
+type RDPNTLMInfo struct {
+	TargetName          string `json:"target_name,omitempty"`
+	NetBIOSDomainName   string `json:"netbios_domain_name,omitempty"`
+	NetBIOSComputerName string `json:"netbios_computer_name,omitempty"`
+	DNSDomainName       string `json:"dns_domain_name,omitempty"`
+	DNSComputerName     string `json:"dns_computer_name,omitempty"`
+	DNSTreeName         string `json:"dns_tree_name,omitempty"`
+	ProductVersion      string `json:"product_version,omitempty"`
+	SystemTime          string `json:"system_time,omitempty"`
+}
+
+// ParseRDPNTLMInfo parses rdp-ntlm-info output.
+func ParseRDPNTLMInfo(script nmap.Script) *RDPNTLMInfo {
+	values := tableMap(nmap.Table{Elements: script.Elements})
+	if len(values) == 0 {
+		values = outputFields(script.Output)
+	}
+
+	return &RDPNTLMInfo{
+		TargetName:          values["Target_Name"],
+		NetBIOSDomainName:   values["NetBIOS_Domain_Name"],
+		NetBIOSComputerName: values["NetBIOS_Computer_Name"],
+		DNSDomainName:       values["DNS_Domain_Name"],
+		DNSComputerName:     values["DNS_Computer_Name"],
+		DNSTreeName:         values["DNS_Tree_Name"],
+		ProductVersion:      values["Product_Version"],
+		SystemTime:          values["System_Time"],
+	}
+}
+
+// Vulnerability is a single entry of the vulners script output.
+type Vulnerability struct {
+	ID        string  `json:"id"`
+	Type      string  `json:"type"`
+	CVSS      float64 `json:"cvss"`
+	IsExploit bool    `json:"is_exploit"`
+}
+
+// VulnersCPE is a CPE the vulners script looked up and what it found.
+type VulnersCPE struct {
+	CPE             string          `json:"cpe"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+}
+
+var (
+	vulnersCPERe  = regexp.MustCompile(`^\s*(cpe:/\S+):\s*$`)
+	vulnersVulnRe = regexp.MustCompile(`^\s*(\S+)\s+(\d+(?:\.\d+)?)\s+https?://vulners\.com/(\w+)/\S+(\s+\*EXPLOIT\*)?`)
+)
+
+// ParseVulners parses vulners output. Entries with a malformed score are
+// skipped and reported in the returned error, along with the other entries.
+func ParseVulners(script nmap.Script) ([]VulnersCPE, error) {
+	var cpes []VulnersCPE
+	var errs []error
+	for _, table := range script.Tables {
+		cpe := VulnersCPE{CPE: table.Key}
+		for _, vulnTable := range table.Tables {
+			values := tableMap(vulnTable)
+			vuln := Vulnerability{
+				ID:        values["id"],
+				Type:      values["type"],
+				IsExploit: values["is_exploit"] == "true",
+			}
+
+			if values["cvss"] != "" {
+				var err error
+				vuln.CVSS, err = strconv.ParseFloat(values["cvss"], 64)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("invalid CVSS score for %s: %w", vuln.ID, err))
+					continue
+				}
+			}
+			cpe.Vulnerabilities = append(cpe.Vulnerabilities, vuln)
+		}
+		cpes = append(cpes, cpe)
+	}
+
+	if len(cpes) > 0 {
+		return cpes, errors.Join(errs...)
+	}
+
+	for _, line := range strings.Split(script.Output, "\n") {
+		if match := vulnersCPERe.FindStringSubmatch(line); match != nil {
+			cpes = append(cpes, VulnersCPE{CPE: match[1]})
+			continue
+		}
+
+		match := vulnersVulnRe.FindStringSubmatch(line)
+		if match == nil || len(cpes) == 0 {
+			continue
+		}
+
+		cvss, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid CVSS score for %s: %w", match[1], err))
+			continue
+		}
+
+		cpe := &cpes[len(cpes)-1]
+		cpe.Vulnerabilities = append(cpe.Vulnerabilities, Vulnerability{
+			ID:        match[1],
+			Type:      match[3],
+			CVSS:      cvss,
+			IsExploit: match[4] != "",
+		})
+	}
+	return cpes, errors.Join(errs...)
+}
+
+// VulscanEntry is a single entry of the vulscan script output.
+type VulscanEntry struct {
+	Database    string `json:"database"`
+	ID          string `json:"id"`
+	Description string `json:"description"`
+}
+
+var (
+	vulscanDatabaseRe = regexp.MustCompile(`^\|?\s*(?:vulscan:\s*)?(.+?) - https?://\S+:\s*$`)
+	vulscanEntryRe    = regexp.MustCompile(`^\|?\s*\[([^\]]+)\]\s*(.*)$`)
+)
+
+// ParseVulscan parses vulscan output, which only has text output.
+func ParseVulscan(script nmap.Script) []VulscanEntry {
+	var entries []VulscanEntry
+	database := ""
+	for _, line := range strings.Split(script.Output, "\n") {
+		if match := vulscanDatabaseRe.FindStringSubmatch(line); match != nil {
+			database = strings.TrimSpace(match[1])
+			continue
+		}
+
+		if match := vulscanEntryRe.FindStringSubmatch(line); match != nil {
+			entries = append(entries, VulscanEntry{
+				Database:    database,
+				ID:          match[1],
+				Description: strings.TrimSpace(match[2]),
+			})
+		}
+	}
+	return entries
+}
@@ -0,0 +1,178 @@
+package nmap
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+func TestParseSSLCert(t *testing.T) {
+	tests := []struct {
+		name   string
+		script nmap.Script
+	}{
+		{
+			name: "structured",
+			script: nmap.Script{
+				ID: "ssl-cert",
+				Tables: []nmap.Table{
+					{Key: "subject", Elements: []nmap.Element{{Key: "commonName", Value: "app.example.com"}}},
+					{Key: "issuer", Elements: []nmap.Element{{Key: "commonName", Value: "Example CA"}}},
+					{Key: "pubkey", Elements: []nmap.Element{{Key: "type", Value: "rsa"}, {Key: "bits", Value: "2048"}}},
+					{Key: "extensions", Tables: []nmap.Table{{Elements: []nmap.Element{
+						{Key: "name", Value: "X509v3 Subject Alternative Name"},
+						{Key: "value", Value: "DNS:app.example.com, IP Address:10.0.0.1"},
+					}}}},
+					{Key: "validity", Elements: []nmap.Element{
+						{Key: "notBefore", Value: "2024-01-01T00:00:00"},
+						{Key: "notAfter", Value: "2025-01-01T00:00:00"},
+					}},
+				},
+				Elements: []nmap.Element{{Key: "sig_algo", Value: "sha256WithRSAEncryption"}},
+			},
+		},
+		{
+			name: "text output",
+			script: nmap.Script{
+				ID: "ssl-cert",
+				Output: "Subject: commonName=app.example.com\n" +
+					"Subject Alternative Name: DNS:app.example.com, IP Address:10.0.0.1\n" +
+					"Issuer: commonName=Example CA\n" +
+					"Public Key type: rsa\n" +
+					"Public Key bits: 2048\n" +
+					"Signature Algorithm: sha256WithRSAEncryption\n" +
+					"Not valid before: 2024-01-01T00:00:00\n" +
+					"Not valid after:  2025-01-01T00:00:00\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert, err := ParseSSLCert(tt.script)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := fmt.Sprintf("%s %s %v %s %d %s %s", cert.CommonName(), cert.Issuer["commonName"], cert.DNSNames(),
+				cert.KeyType, cert.KeyBits, cert.SignatureAlgorithm, cert.NotAfter.Format(time.DateOnly))
+			want := "app.example.com Example CA [app.example.com] rsa 2048 sha256WithRSAEncryption 2025-01-01"
+			if got != want {
+				t.Errorf("ParseSSLCert() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestParseVulnersMalformedScore(t *testing.T) {
+	script := nmap.Script{ID: "vulners", Tables: []nmap.Table{{
+		Key: "cpe:/a:openbsd:openssh:7.4",
+		Tables: []nmap.Table{
+			{Elements: []nmap.Element{{Key: "id", Value: "CVE-2099-0001"}, {Key: "cvss", Value: "n/a"}}},
+			{Elements: []nmap.Element{{Key: "id", Value: "CVE-2018-15473"}, {Key: "cvss", Value: "5.3"}}},
+		},
+	}}}
+
+	cpes, err := ParseVulners(script)
+	if err == nil || !strings.Contains(err.Error(), "CVE-2099-0001") {
+		t.Errorf("ParseVulners() error = %v, want an error for CVE-2099-0001", err)
+	}
+
+	if len(cpes) != 1 || len(cpes[0].Vulnerabilities) != 1 || cpes[0].Vulnerabilities[0].ID != "CVE-2018-15473" {
+		t.Errorf("ParseVulners() = %v, want only CVE-2018-15473", cpes)
+	}
+}
+
+func TestParseVulners(t *testing.T) {
+	tests := []struct {
+		name   string
+		script nmap.Script
+	}{
+		{
+			name: "structured",
+			script: nmap.Script{ID: "vulners", Tables: []nmap.Table{{
+				Key: "cpe:/a:openbsd:openssh:7.4",
+				Tables: []nmap.Table{
+					{Elements: []nmap.Element{{Key: "id", Value: "CVE-2023-38408"}, {Key: "type", Value: "cve"}, {Key: "cvss", Value: "9.8"}, {Key: "is_exploit", Value: "true"}}},
+					{Elements: []nmap.Element{{Key: "id", Value: "CVE-2018-15473"}, {Key: "type", Value: "cve"}, {Key: "cvss", Value: "5.3"}, {Key: "is_exploit", Value: "false"}}},
+				},
+			}}},
+		},
+		{
+			name: "text output",
+			script: nmap.Script{ID: "vulners", Output: "\n  cpe:/a:openbsd:openssh:7.4: \n" +
+				"    \tCVE-2023-38408\t9.8\thttps://vulners.com/cve/CVE-2023-38408\t*EXPLOIT*\n" +
+				"    \tCVE-2018-15473\t5.3\thttps://vulners.com/cve/CVE-2018-15473\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cpes, err := ParseVulners(tt.script)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, cpe := range cpes {
+				for _, vuln := range cpe.Vulnerabilities {
+					got = append(got, fmt.Sprintf("%s %s %s %.1f %t", cpe.CPE, vuln.ID, vuln.Type, vuln.CVSS, vuln.IsExploit))
+				}
+			}
+
+			want := []string{
+				"cpe:/a:openbsd:openssh:7.4 CVE-2023-38408 cve 9.8 true",
+				"cpe:/a:openbsd:openssh:7.4 CVE-2018-15473 cve 5.3 false",
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("ParseVulners() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseSSHHostKeys(t *testing.T) {
+	script := nmap.Script{ID: "ssh-hostkey", Output: "\n  2048 aa:bb:cc (RSA)\n  256 dd:ee:ff (ED25519)"}
+	keys, err := ParseSSHHostKeys(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, key := range keys {
+		got = append(got, fmt.Sprintf("%s %d %s", key.Type, key.Bits, key.Fingerprint))
+	}
+
+	want := []string{"rsa 2048 aabbcc", "ed25519 256 ddeeff"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ParseSSHHostKeys() = %v, want %v", got, want)
+	}
+}
+
+func TestViewColumns(t *testing.T) {
+	h := &nmap.Host{
+		HostScripts: []nmap.Script{{ID: "smb-security-mode", Elements: []nmap.Element{{Key: "message_signing", Value: "disabled"}}}},
+		Ports: []nmap.Port{
+			{ID: 80, State: nmap.State{State: "open"}, Scripts: []nmap.Script{{ID: "http-title", Output: "Welcome &amp; hello", Elements: []nmap.Element{{Key: "title", Value: "Welcome &amp; hello"}}}}},
+			{ID: 8080, State: nmap.State{State: "closed"}, Scripts: []nmap.Script{{ID: "http-title", Output: "Closed"}}},
+		},
+	}
+
+	tests := []struct {
+		column string
+		want   []string
+	}{
+		{column: "title", want: []string{"80: Welcome & hello"}},
+		{column: "smb_signing", want: []string{"disabled"}},
+		{column: "server"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.column, func(t *testing.T) {
+			if got := viewColumns[tt.column].value(nil, h); !slices.Equal(got, tt.want) {
+				t.Errorf("%s column = %v, want %v", tt.column, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package nmap
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+// Ways to group services by.
+const (
+	GroupByVersion = "version"
+	GroupByProduct = "product"
+	GroupByCPE     = "cpe"
+)
+
+// ServiceFilter selects the services returned by GetServices.
+type ServiceFilter struct {
+	// Service has to match the nmap service name, ignoring case.
+	Service string
+	// Product has to be part of the product name, ignoring case.
+	Product string
+	// Versions the service version has to satisfy.
+	Versions VersionConstraints
+	// GroupBy is one of GroupByVersion, GroupByProduct or GroupByCPE.
+	GroupBy string
+}
+
+func (f ServiceFilter) matches(svc nmap.Service) bool {
+	if f.Service != "" && !strings.EqualFold(svc.Name, f.Service) {
+		return false
+	}
+
+	if f.Product != "" && !strings.Contains(strings.ToLower(svc.Product), strings.ToLower(f.Product)) {
+		return false
+	}
+
+	return f.Versions.Matches(svc.Version)
+}
+
+// ServiceGroup is a service found on one or more open ports. Endpoints are
+// like "10.0.0.1:53/udp".
+type ServiceGroup struct {
+	Name      string   `json:"name"`
+	Product   string   `json:"product"`
+	Version   string   `json:"version,omitempty"`
+	CPE       string   `json:"cpe,omitempty"`
+	Hosts     int      `json:"hosts"`
+	Endpoints []string `json:"endpoints"`
+
+	hosts map[*nmap.Host]bool
+}
+
+// GetServices groups the open ports of the matching hosts by service, most
+// common services first. Hosts with several addresses are counted once.
+func (v *View) GetServices(options ViewOptions, filter ServiceFilter) []ServiceGroup {
+	groups := map[string]*ServiceGroup{}
+	var keys []string
+
+	for _, h := range v.GetHostsWithOptions(options | ViewOpenPorts) {
+		for _, port := range h.Ports {
+			if !portIsOpen(&port) || !filter.matches(port.Service) {
+				continue
+			}
+
+			for _, group := range serviceGroups(port.Service, filter.GroupBy) {
+				key := strings.Join([]string{group.Name, group.Product, group.Version, group.CPE}, "\x00")
+				existing, ok := groups[key]
+				if !ok {
+					existing = &group
+					existing.hosts = map[*nmap.Host]bool{}
+					groups[key] = existing
+					keys = append(keys, key)
+				}
+
+				existing.hosts[h] = true
+				for _, addr := range h.Addresses {
+					if net.ParseIP(addr.Addr) == nil {
+						continue
+					}
+
+					endpoint := net.JoinHostPort(addr.Addr, fmt.Sprint(port.ID)) + "/" + newPortKey(port).protocol
+					if !slices.Contains(existing.Endpoints, endpoint) {
+						existing.Endpoints = append(existing.Endpoints, endpoint)
+					}
+				}
+			}
+		}
+	}
+
+	var services []ServiceGroup
+	for _, key := range keys {
+		group := groups[key]
+		group.Hosts = len(group.hosts)
+		slices.Sort(group.Endpoints)
+		services = append(services, *group)
+	}
+
+	slices.SortStableFunc(services, func(a, b ServiceGroup) int {
+		if a.Hosts != b.Hosts {
+			return b.Hosts - a.Hosts
+		}
+		return strings.Compare(a.Name+a.Product+a.Version+a.CPE, b.Name+b.Product+b.Version+b.CPE)
+	})
+	return services
+}
+
+// serviceGroups returns the groups a service belongs to. A service belongs
+// to one group per CPE when grouping by CPE.
+func serviceGroups(svc nmap.Service, groupBy string) []ServiceGroup {
+	group := ServiceGroup{
+		Name:    svc.Name,
+		Product: svc.Product,
+	}
+
+	switch groupBy {
+	case GroupByProduct:
+		return []ServiceGroup{group}
+	case GroupByCPE:
+		if len(svc.CPEs) == 0 {
+			return []ServiceGroup{group}
+		}
+
+		var groups []ServiceGroup
+		for _, cpe := range svc.CPEs {
+			group.CPE = string(cpe)
+			groups = append(groups, group)
+		}
+		return groups
+	}
+
+	group.Version = svc.Version
+	return []ServiceGroup{group}
+}
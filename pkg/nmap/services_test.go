@@ -0,0 +1,34 @@
+package nmap
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+func TestGetServicesEndpoints(t *testing.T) {
+	dns := nmap.Service{Name: "domain", Product: "dnsmasq", Version: "2.89"}
+	run := &nmap.Run{Hosts: []nmap.Host{{
+		Status:    nmap.Status{State: "up"},
+		Addresses: []nmap.Address{{Addr: "10.0.0.1", AddrType: "ipv4"}, {Addr: "2001:db8::1", AddrType: "ipv6"}},
+		Ports: []nmap.Port{
+			{ID: 53, Protocol: "tcp", State: nmap.State{State: "open"}, Service: dns},
+			{ID: 53, Protocol: "udp", State: nmap.State{State: "open"}, Service: dns},
+		},
+	}}}
+
+	services := NewNmapView(run).GetServices(0, ServiceFilter{GroupBy: GroupByVersion})
+	if len(services) != 1 {
+		t.Fatalf("GetServices() = %v, want one group", services)
+	}
+
+	want := []string{"10.0.0.1:53/tcp", "10.0.0.1:53/udp", "[2001:db8::1]:53/tcp", "[2001:db8::1]:53/udp"}
+	if got := services[0].Endpoints; !slices.Equal(got, want) {
+		t.Errorf("GetServices() endpoints = %v, want %v", got, want)
+	}
+
+	if services[0].Hosts != 1 {
+		t.Errorf("GetServices() hosts = %d, want 1", services[0].Hosts)
+	}
+}
@@ -0,0 +1,139 @@
+package nmap
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+// ScanDuration is how long a single scan ran for, from its runstats.
+type ScanDuration struct {
+	Path      string    `json:"path"`
+	Args      string    `json:"args"`
+	Start     time.Time `json:"start"`
+	Elapsed   float64   `json:"elapsed"`
+	HostsUp   int       `json:"hosts_up"`
+	HostsDown int       `json:"hosts_down"`
+	Exit      string    `json:"exit"`
+}
+
+// Stats are the headline numbers of a set of scans.
+type Stats struct {
+	Hosts              int            `json:"hosts"`
+	Up                 int            `json:"up"`
+	Down               int            `json:"down"`
+	UpReasons          []Count        `json:"up_reasons"`
+	DownReasons        []Count        `json:"down_reasons"`
+	HostsWithOpenPorts int            `json:"hosts_with_open_ports"`
+	PublicHosts        int            `json:"public_hosts"`
+	PrivateHosts       int            `json:"private_hosts"`
+	OpenPorts          int            `json:"open_ports"`
+	TCPWrapped         int            `json:"tcpwrapped"`
+	TCPWrappedRatio    float64        `json:"tcpwrapped_ratio"`
+	TopPorts           []Count        `json:"top_ports"`
+	TopServices        []Count        `json:"top_services"`
+	OSFamilies         []Count        `json:"os_families"`
+	Scans              []ScanDuration `json:"scans"`
+	TotalDuration      float64        `json:"total_duration"`
+}
+
+// GetStats computes the stats of the hosts in the view, keeping the top most
+// common ports and services. Scan durations come from the individual scans,
+// since a merged run only keeps the runstats of its first scan.
+func (v *View) GetStats(options ViewOptions, top int, scans []*Scan) Stats {
+	stats := Stats{}
+	upReasons := map[string]int{}
+	downReasons := map[string]int{}
+	ports := map[string]int{}
+	services := map[string]int{}
+	osFamilies := map[string]int{}
+
+	for _, h := range v.GetHostsWithOptions(options) {
+		stats.Hosts++
+		switch h.Status.State {
+		case "up":
+			stats.Up++
+			upReasons[h.Status.Reason]++
+		case "down":
+			stats.Down++
+			downReasons[h.Status.Reason]++
+		}
+
+		hasPrivateIPs, hasPublicIPs := addressKinds(h)
+		if hasPrivateIPs {
+			stats.PrivateHosts++
+		}
+
+		if hasPublicIPs {
+			stats.PublicHosts++
+		}
+
+		if hasOpenPorts(h) {
+			stats.HostsWithOpenPorts++
+		}
+
+		for _, port := range h.Ports {
+			if !portIsOpen(&port) {
+				continue
+			}
+
+			stats.OpenPorts++
+			ports[fmt.Sprintf("%d/%s", port.ID, newPortKey(port).protocol)]++
+
+			service := port.Service.Name
+			if service == "" {
+				service = "unknown"
+			}
+			services[service]++
+
+			if service == "tcpwrapped" {
+				stats.TCPWrapped++
+			}
+		}
+
+		if family := osFamily(h); family != "" {
+			osFamilies[family]++
+		}
+	}
+
+	if stats.OpenPorts > 0 {
+		stats.TCPWrappedRatio = float64(stats.TCPWrapped) / float64(stats.OpenPorts)
+	}
+
+	stats.UpReasons = topCounts(upReasons, 0)
+	stats.DownReasons = topCounts(downReasons, 0)
+	stats.TopPorts = topCounts(ports, top)
+	stats.TopServices = topCounts(services, top)
+	stats.OSFamilies = topCounts(osFamilies, 0)
+
+	for _, scan := range scans {
+		finished := scan.Run.Stats.Finished
+		stats.Scans = append(stats.Scans, ScanDuration{
+			Path:      scan.Path,
+			Args:      scan.Run.Args,
+			Start:     time.Time(scan.Run.Start),
+			Elapsed:   float64(finished.Elapsed),
+			HostsUp:   scan.Run.Stats.Hosts.Up,
+			HostsDown: scan.Run.Stats.Hosts.Down,
+			Exit:      finished.Exit,
+		})
+		stats.TotalDuration += float64(finished.Elapsed)
+	}
+	return stats
+}
+
+// osFamily returns the OS family of the most accurate OS match of the host.
+func osFamily(h *nmap.Host) string {
+	var best *nmap.OSMatch
+	for i, match := range h.OS.Matches {
+		if len(match.Classes) > 0 && (best == nil || match.Accuracy > best.Accuracy) {
+			best = &h.OS.Matches[i]
+		}
+	}
+
+	if best == nil {
+		return ""
+	}
+	return best.Classes[0].Family
+}
@@ -0,0 +1,43 @@
+package nmap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+func TestGetStats(t *testing.T) {
+	run, err := nmap.Parse([]byte(viewTestXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		options ViewOptions
+		want    string
+	}{
+		{
+			name: "all hosts",
+			want: "hosts=4 up=3 down=1 open=3 public=2 private=2 ports=6 tcpwrapped=2 top=[22/tcp (2) 443/tcp (1)]",
+		},
+		{
+			name:    "no tcpwrapped",
+			options: IgnoreTCPWrapped,
+			want:    "hosts=3 up=2 down=1 open=2 public=1 private=2 ports=4 tcpwrapped=0 top=[22/tcp (2) 443/tcp (1)]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := NewNmapView(run).GetStats(tt.options, 2, nil)
+			got := fmt.Sprintf("hosts=%d up=%d down=%d open=%d public=%d private=%d ports=%d tcpwrapped=%d top=%v",
+				stats.Hosts, stats.Up, stats.Down, stats.HostsWithOpenPorts, stats.PublicHosts, stats.PrivateHosts,
+				stats.OpenPorts, stats.TCPWrapped, stats.TopPorts)
+
+			if got != tt.want {
+				t.Errorf("GetStats() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
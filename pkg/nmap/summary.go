@@ -0,0 +1,195 @@
+package nmap
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+// PortSummary is an open port and the hosts it is open on.
+type PortSummary struct {
+	Port      uint16   `json:"port"`
+	Protocol  string   `json:"protocol"`
+	Services  []string `json:"services"`
+	Hosts     int      `json:"hosts"`
+	Addresses []string `json:"addresses"`
+}
+
+// GetPortSummaries returns each open port with the hosts behind it, most
+// common ports first. Hosts with several addresses are counted once.
+func (v *View) GetPortSummaries(options ViewOptions) []PortSummary {
+	summaries := map[portKey]*PortSummary{}
+	for _, h := range v.GetHostsWithOptions(options | ViewOpenPorts) {
+		for _, port := range h.Ports {
+			if !portIsOpen(&port) {
+				continue
+			}
+
+			key := newPortKey(port)
+			summary, ok := summaries[key]
+			if !ok {
+				summary = &PortSummary{Port: port.ID, Protocol: key.protocol}
+				summaries[key] = summary
+			}
+
+			summary.Hosts++
+			if port.Service.Name != "" && !slices.Contains(summary.Services, port.Service.Name) {
+				summary.Services = append(summary.Services, port.Service.Name)
+			}
+
+			for _, addr := range h.Addresses {
+				if net.ParseIP(addr.Addr) != nil {
+					summary.Addresses = append(summary.Addresses, addr.Addr)
+				}
+			}
+		}
+	}
+
+	var ports []PortSummary
+	for _, summary := range summaries {
+		slices.Sort(summary.Services)
+		slices.Sort(summary.Addresses)
+		ports = append(ports, *summary)
+	}
+
+	slices.SortFunc(ports, func(a, b PortSummary) int {
+		if a.Hosts != b.Hosts {
+			return b.Hosts - a.Hosts
+		}
+		if a.Port != b.Port {
+			return int(a.Port) - int(b.Port)
+		}
+		return protocolIndex(a.Protocol) - protocolIndex(b.Protocol)
+	})
+	return ports
+}
+
+// Count is the number of times something, such as a service, was seen.
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func (c Count) String() string {
+	return fmt.Sprintf("%s (%d)", c.Name, c.Count)
+}
+
+// SubnetSummary is what was found in a single subnet.
+type SubnetSummary struct {
+	Subnet      string  `json:"subnet"`
+	LiveHosts   int     `json:"live_hosts"`
+	OpenPorts   int     `json:"open_ports"`
+	TopServices []Count `json:"top_services"`
+}
+
+// GetSubnetSummaries groups the live hosts by subnet, using prefixLen for
+// IPv4 and prefixLen6 for IPv6 addresses. A host with several addresses in
+// one subnet is counted once, while a host in several subnets is counted in
+// each of them.
+func (v *View) GetSubnetSummaries(options ViewOptions, prefixLen int, prefixLen6 int, topServices int) ([]SubnetSummary, error) {
+	type subnet struct {
+		summary  *SubnetSummary
+		services map[string]int
+	}
+
+	subnets := map[netip.Prefix]*subnet{}
+	for _, h := range v.GetHostsWithOptions(options) {
+		if h.Status.State != "up" && !hasOpenPorts(h) {
+			continue
+		}
+
+		prefixes, err := hostPrefixes(h, prefixLen, prefixLen6)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, prefix := range prefixes {
+			s, ok := subnets[prefix]
+			if !ok {
+				s = &subnet{
+					summary:  &SubnetSummary{Subnet: prefix.String()},
+					services: map[string]int{},
+				}
+				subnets[prefix] = s
+			}
+
+			s.summary.LiveHosts++
+			for _, port := range h.Ports {
+				if portIsOpen(&port) {
+					s.summary.OpenPorts++
+					service := port.Service.Name
+					if service == "" {
+						service = "unknown"
+					}
+					s.services[service]++
+				}
+			}
+		}
+	}
+
+	var prefixes []netip.Prefix
+	for prefix := range subnets {
+		prefixes = append(prefixes, prefix)
+	}
+	slices.SortFunc(prefixes, func(a, b netip.Prefix) int {
+		return a.Addr().Compare(b.Addr())
+	})
+
+	var summaries []SubnetSummary
+	for _, prefix := range prefixes {
+		s := subnets[prefix]
+		s.summary.TopServices = topCounts(s.services, topServices)
+		summaries = append(summaries, *s.summary)
+	}
+	return summaries, nil
+}
+
+// hostPrefixes returns the distinct subnets of the host's addresses.
+func hostPrefixes(h *nmap.Host, prefixLen int, prefixLen6 int) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, a := range h.Addresses {
+		addr, err := netip.ParseAddr(a.Addr)
+		if err != nil {
+			continue
+		}
+
+		bits := prefixLen
+		if !addr.Is4() {
+			bits = prefixLen6
+		}
+
+		prefix, err := addr.Prefix(bits)
+		if err != nil {
+			return nil, err
+		}
+
+		if !slices.Contains(prefixes, prefix) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes, nil
+}
+
+// topCounts returns the top most common names, or all of them if top is 0.
+func topCounts(names map[string]int, top int) []Count {
+	var counts []Count
+	for name, count := range names {
+		counts = append(counts, Count{Name: name, Count: count})
+	}
+
+	slices.SortFunc(counts, func(a, b Count) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	if top > 0 && len(counts) > top {
+		counts = counts[:top]
+	}
+	return counts
+}
@@ -0,0 +1,94 @@
+package nmap
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+const summaryTestXML = `<?xml version="1.0" encoding="UTF-8"?>
+<nmaprun scanner="nmap" args="nmap" start="1000" version="7.94" xmloutputversion="1.05">
+<host><status state="up" reason="syn-ack"/>
+<address addr="10.0.0.1" addrtype="ipv4"/>
+<address addr="10.0.0.2" addrtype="ipv4"/>
+<address addr="10.0.1.1" addrtype="ipv4"/>
+<ports>
+<port protocol="tcp" portid="22"><state state="open" reason="syn-ack"/><service name="ssh" method="probed" conf="10"/></port>
+<port protocol="tcp" portid="80"><state state="open" reason="syn-ack"/><service name="http" method="probed" conf="10"/></port>
+</ports>
+</host>
+<host><status state="up" reason="syn-ack"/>
+<address addr="10.0.0.5" addrtype="ipv4"/>
+<ports>
+<port protocol="tcp" portid="80"><state state="open" reason="syn-ack"/><service name="http" method="probed" conf="10"/></port>
+<port protocol="udp" portid="53"><state state="open" reason="udp-response"/><service name="domain" method="probed" conf="10"/></port>
+</ports>
+</host>
+<host><status state="down" reason="no-response"/>
+<address addr="10.0.0.9" addrtype="ipv4"/>
+</host>
+</nmaprun>`
+
+func TestGetPortSummaries(t *testing.T) {
+	run, err := nmap.Parse([]byte(summaryTestXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, port := range NewNmapView(run).GetPortSummaries(0) {
+		got = append(got, fmt.Sprintf("%d/%s:%d:%v", port.Port, port.Protocol, port.Hosts, port.Addresses))
+	}
+
+	want := []string{
+		"80/tcp:2:[10.0.0.1 10.0.0.2 10.0.0.5 10.0.1.1]",
+		"22/tcp:1:[10.0.0.1 10.0.0.2 10.0.1.1]",
+		"53/udp:1:[10.0.0.5]",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("GetPortSummaries() = %v, want %v", got, want)
+	}
+}
+
+func TestGetSubnetSummaries(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix int
+		want   []string
+	}{
+		{
+			name:   "multi-address host counted once per subnet",
+			prefix: 24,
+			want:   []string{"10.0.0.0/24:2:4:[http (2) domain (1) ssh (1)]", "10.0.1.0/24:1:2:[http (1) ssh (1)]"},
+		},
+		{
+			name:   "wider prefix",
+			prefix: 16,
+			want:   []string{"10.0.0.0/16:2:4:[http (2) domain (1) ssh (1)]"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, err := nmap.Parse([]byte(summaryTestXML))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			subnets, err := NewNmapView(run).GetSubnetSummaries(0, tt.prefix, 64, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, subnet := range subnets {
+				got = append(got, fmt.Sprintf("%s:%d:%d:%v", subnet.Subnet, subnet.LiveHosts, subnet.OpenPorts, subnet.TopServices))
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GetSubnetSummaries() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
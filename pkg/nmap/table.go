@@ -0,0 +1,135 @@
+package nmap
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+)
+
+// RenderTable prints the rows as a table in the style used by every nex
+// table output.
+func RenderTable(out io.Writer, headers []string, rows [][]string) {
+	re := lipgloss.NewRenderer(out)
+	baseStyle := re.NewStyle().Padding(0, 1)
+	headerStyle := baseStyle.Foreground(lipgloss.Color("252")).Bold(true)
+
+	CapitalizeHeaders := func(data []string) []string {
+		capitalized := make([]string, len(data))
+		for i := range data {
+			capitalized[i] = strings.ToUpper(data[i])
+		}
+		return capitalized
+	}
+
+	ct := table.New().
+		Border(lipgloss.NormalBorder()).
+		BorderStyle(re.NewStyle().Foreground(lipgloss.Color("238"))).
+		Headers(CapitalizeHeaders(headers)...).
+		Rows(rows...).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == 0 {
+				return headerStyle
+			}
+
+			even := row%2 == 0
+
+			if even {
+				return baseStyle.Foreground(lipgloss.Color("245"))
+			}
+			return baseStyle.Foreground(lipgloss.Color("252"))
+		})
+
+	fmt.Fprintln(out, ct)
+}
+
+// WriteCSV writes the headers and rows as CSV.
+func WriteCSV(out io.Writer, headers []string, rows [][]string) error {
+	w := csv.NewWriter(out)
+	err := w.Write(headers)
+	if err != nil {
+		return err
+	}
+
+	err = w.WriteAll(rows)
+	if err != nil {
+		return err
+	}
+	return w.Error()
+}
+
+// RenderBarChart prints the counts as a horizontal bar chart, scaling the
+// longest bar to width characters.
+func RenderBarChart(out io.Writer, title string, counts []Count, width int) {
+	re := lipgloss.NewRenderer(out)
+	titleStyle := re.NewStyle().Foreground(lipgloss.Color("252")).Bold(true)
+	labelStyle := re.NewStyle().Foreground(lipgloss.Color("245"))
+	barStyle := re.NewStyle().Foreground(lipgloss.Color("63"))
+
+	fmt.Fprintln(out, titleStyle.Render(title))
+	if len(counts) == 0 {
+		fmt.Fprintln(out, labelStyle.Render("  none"))
+		return
+	}
+
+	labelWidth := 0
+	maxCount := 0
+	for _, c := range counts {
+		labelWidth = max(labelWidth, lipgloss.Width(c.Name))
+		maxCount = max(maxCount, c.Count)
+	}
+
+	for _, c := range counts {
+		bar := 0
+		if maxCount > 0 {
+			bar = max(1, c.Count*width/maxCount)
+		}
+
+		fmt.Fprintf(out, "  %s %s %d\n",
+			labelStyle.Width(labelWidth).Render(c.Name),
+			barStyle.Render(strings.Repeat("█", bar)),
+			c.Count,
+		)
+	}
+	fmt.Fprintln(out)
+}
+
+// WriteMarkdown writes the headers and rows as a Markdown table.
+func WriteMarkdown(out io.Writer, headers []string, rows [][]string) error {
+	escape := strings.NewReplacer("|", `\|`, "\n", "<br>")
+	writeRow := func(cells []string) error {
+		escaped := make([]string, len(cells))
+		for i, cell := range cells {
+			escaped[i] = escape.Replace(cell)
+		}
+
+		_, err := fmt.Fprintf(out, "| %s |\n", strings.Join(escaped, " | "))
+		return err
+	}
+
+	err := writeRow(headers)
+	if err != nil {
+		return err
+	}
+
+	separator := make([]string, len(headers))
+	for i := range separator {
+		separator[i] = "---"
+	}
+
+	err = writeRow(separator)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		err = writeRow(row)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
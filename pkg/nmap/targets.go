@@ -0,0 +1,283 @@
+package nmap
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+// Target is an open port on a host, ready to be handed to other tools.
+type Target struct {
+	Host     string `json:"host"`
+	Port     uint16 `json:"port"`
+	Protocol string `json:"protocol"`
+	Service  string `json:"service"`
+}
+
+// HostPort returns the target as host:port.
+func (t Target) HostPort() string {
+	return net.JoinHostPort(t.Host, fmt.Sprint(t.Port))
+}
+
+// URL returns the target as service://host:port.
+func (t Target) URL() string {
+	return fmt.Sprintf("%s://%s", t.Service, t.HostPort())
+}
+
+// TargetGroup is a set of hosts sharing the exact same open ports.
+type TargetGroup struct {
+	Hosts []string `json:"hosts"`
+	Ports []Target `json:"ports"`
+}
+
+// PortList returns the ports in nmap -p format, prefixing them with their
+// protocol (T:, U:, S:) when they are not all TCP.
+func (g TargetGroup) PortList() string {
+	return portList(g.Ports)
+}
+
+// ScanTypes returns the nmap scan types needed to scan the ports of the
+// group. nmap only scans TCP by default, so UDP and SCTP ports need -sU and
+// -sY, along with -sS when there are TCP ports too.
+func (g TargetGroup) ScanTypes() []string {
+	var scanTypes []string
+	for _, port := range g.Ports {
+		var scanType string
+		switch port.Protocol {
+		case ProtocolUDP:
+			scanType = "-sU"
+		case ProtocolSCTP:
+			scanType = "-sY"
+		default:
+			scanType = "-sS"
+		}
+
+		if !slices.Contains(scanTypes, scanType) {
+			scanTypes = append(scanTypes, scanType)
+		}
+	}
+
+	if slices.Equal(scanTypes, []string{"-sS"}) {
+		return nil
+	}
+
+	slices.Sort(scanTypes)
+	return scanTypes
+}
+
+// NmapArgs returns the nmap arguments scanning the ports of the group on the
+// hosts listed in the file at hostsPath.
+func (g TargetGroup) NmapArgs(hostsPath string) []string {
+	return append(g.ScanTypes(), "-p", g.PortList(), "-iL", hostsPath)
+}
+
+// GetTargets returns a target for each open port of the matching hosts.
+// Hostnames are included as targets alongside IPs when hostnames is true.
+func (v *View) GetTargets(options ViewOptions, hostnames bool) []Target {
+	var targets []Target
+	// tcpwrapped ports don't have a service to hand to other tools
+	for _, h := range v.GetHostsWithOptions(options | ViewOpenPorts | IgnoreTCPWrapped) {
+		names := targetAddresses(h)
+		if hostnames {
+			for _, hostname := range h.Hostnames {
+				names = append(names, hostname.Name)
+			}
+		}
+
+		targets = append(targets, v.hostTargets(h, names)...)
+	}
+
+	slices.SortFunc(targets, compareTargets)
+	return slices.Compact(targets)
+}
+
+// GetScanGroups groups the matching hosts with identical sets of open ports,
+// so a single nmap invocation can cover each group. Each host is listed
+// once, by its first hostname when hostnames is true and it has one,
+// otherwise by its first address, so no host is scanned twice.
+func (v *View) GetScanGroups(options ViewOptions, hostnames bool) []TargetGroup {
+	var targets []Target
+	for _, h := range v.GetHostsWithOptions(options | ViewOpenPorts | IgnoreTCPWrapped) {
+		names := targetAddresses(h)
+		if hostnames && len(h.Hostnames) > 0 {
+			names = []string{h.Hostnames[0].Name}
+		}
+
+		if len(names) > 0 {
+			targets = append(targets, v.hostTargets(h, names[:1])...)
+		}
+	}
+
+	slices.SortFunc(targets, compareTargets)
+	return GroupTargetsByPorts(slices.Compact(targets))
+}
+
+// targetAddresses returns the IP addresses of the host, leaving out MAC
+// addresses.
+func targetAddresses(h *nmap.Host) []string {
+	addrs := []string{}
+	for _, addr := range h.Addresses {
+		if net.ParseIP(addr.Addr) != nil {
+			addrs = append(addrs, addr.Addr)
+		}
+	}
+	return addrs
+}
+
+// hostTargets returns a target for each name and port of the host.
+func (v *View) hostTargets(h *nmap.Host, names []string) []Target {
+	var targets []Target
+	for _, port := range h.Ports {
+		// IP protocol scans report protocol numbers, not ports
+		if strings.EqualFold(port.Protocol, ProtocolIP) {
+			continue
+		}
+
+		service := v.urlScheme(&port)
+		if service == "" {
+			service = "unknown"
+		}
+
+		for _, name := range names {
+			targets = append(targets, Target{
+				Host:     name,
+				Port:     port.ID,
+				Protocol: strings.ToLower(port.Protocol),
+				Service:  service,
+			})
+		}
+	}
+	return targets
+}
+
+// WriteTargets writes a target per line as host:port (hostport), "ip port"
+// (ipport) or service://host:port (url).
+func WriteTargets(out io.Writer, format string, targets []Target) error {
+	for _, t := range targets {
+		var line string
+		switch format {
+		case "hostport":
+			line = t.HostPort()
+		case "ipport":
+			line = fmt.Sprintf("%s %d", t.Host, t.Port)
+		case "url":
+			line = t.URL()
+		default:
+			return fmt.Errorf("unknown target format %q, expected hostport, ipport or url", format)
+		}
+
+		if _, err := fmt.Fprintln(out, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MSFTargetFiles returns Metasploit RHOSTS files of the target hosts, named
+// by service, port and protocol. RHOSTS only takes hosts, so the files are
+// split by port as well as service.
+func MSFTargetFiles(targets []Target) map[string][]string {
+	files := map[string][]string{}
+	for _, t := range targets {
+		name := fmt.Sprintf("%s_%d_%s.rhosts", t.Service, t.Port, t.Protocol)
+		files[name] = append(files[name], t.Host)
+	}
+	return files
+}
+
+// ServiceTargetFiles returns host:port files of the targets, named by
+// service.
+func ServiceTargetFiles(targets []Target) map[string][]string {
+	files := map[string][]string{}
+	for service, serviceTargets := range GroupTargetsByService(targets) {
+		name := fmt.Sprintf("%s.txt", service)
+		for _, t := range serviceTargets {
+			files[name] = append(files[name], t.HostPort())
+		}
+	}
+	return files
+}
+
+// GroupTargetsByService groups the targets by their service name.
+func GroupTargetsByService(targets []Target) map[string][]Target {
+	groups := map[string][]Target{}
+	for _, t := range targets {
+		groups[t.Service] = append(groups[t.Service], t)
+	}
+	return groups
+}
+
+// GroupTargetsByPorts groups hosts that have identical sets of open ports,
+// so a single nmap invocation can cover each group.
+func GroupTargetsByPorts(targets []Target) []TargetGroup {
+	hostPorts := map[string][]Target{}
+	var hosts []string
+	for _, t := range targets {
+		if _, ok := hostPorts[t.Host]; !ok {
+			hosts = append(hosts, t.Host)
+		}
+		// the host is the same for every port of the group
+		hostPorts[t.Host] = append(hostPorts[t.Host], Target{Port: t.Port, Protocol: t.Protocol, Service: t.Service})
+	}
+
+	groupIndex := map[string]int{}
+	var groups []TargetGroup
+	for _, host := range hosts {
+		ports := hostPorts[host]
+		slices.SortFunc(ports, compareTargets)
+		key := portList(ports)
+
+		i, ok := groupIndex[key]
+		if !ok {
+			i = len(groups)
+			groupIndex[key] = i
+			groups = append(groups, TargetGroup{Ports: ports})
+		}
+		groups[i].Hosts = append(groups[i].Hosts, host)
+	}
+	return groups
+}
+
+func compareTargets(a, b Target) int {
+	if c := strings.Compare(a.Host, b.Host); c != 0 {
+		return c
+	}
+	if c := protocolIndex(a.Protocol) - protocolIndex(b.Protocol); c != 0 {
+		return c
+	}
+	if c := int(a.Port) - int(b.Port); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Service, b.Service)
+}
+
+var portListPrefixes = map[string]string{
+	ProtocolTCP:  "T:",
+	ProtocolUDP:  "U:",
+	ProtocolSCTP: "S:",
+}
+
+func portList(targets []Target) string {
+	onlyTCP := !slices.ContainsFunc(targets, func(t Target) bool {
+		return t.Protocol != ProtocolTCP
+	})
+
+	var ports []string
+	protocol := ""
+	for _, t := range targets {
+		port := fmt.Sprint(t.Port)
+		if !onlyTCP && t.Protocol != protocol {
+			protocol = t.Protocol
+			port = portListPrefixes[protocol] + port
+		}
+
+		if !slices.Contains(ports, port) {
+			ports = append(ports, port)
+		}
+	}
+	return strings.Join(ports, ",")
+}
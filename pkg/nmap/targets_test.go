@@ -0,0 +1,237 @@
+package nmap
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+func TestGroupTargetsByPorts(t *testing.T) {
+	targets := []Target{
+		{Host: "10.0.0.1", Port: 22, Protocol: "tcp", Service: "ssh"},
+		{Host: "10.0.0.1", Port: 80, Protocol: "tcp", Service: "http"},
+		{Host: "10.0.0.2", Port: 80, Protocol: "tcp", Service: "http"},
+		{Host: "10.0.0.2", Port: 22, Protocol: "tcp", Service: "ssh"},
+		{Host: "10.0.0.3", Port: 53, Protocol: "udp", Service: "domain"},
+		{Host: "10.0.0.3", Port: 22, Protocol: "tcp", Service: "ssh"},
+	}
+
+	groups := GroupTargetsByPorts(targets)
+	if len(groups) != 2 {
+		t.Fatalf("GroupTargetsByPorts() groups = %d, want 2", len(groups))
+	}
+
+	tests := []struct {
+		group     TargetGroup
+		wantHosts int
+		wantPorts string
+	}{
+		{group: groups[0], wantHosts: 2, wantPorts: "22,80"},
+		{group: groups[1], wantHosts: 1, wantPorts: "T:22,U:53"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wantPorts, func(t *testing.T) {
+			if len(tt.group.Hosts) != tt.wantHosts {
+				t.Errorf("Hosts = %v, want %d hosts", tt.group.Hosts, tt.wantHosts)
+			}
+
+			if got := tt.group.PortList(); got != tt.wantPorts {
+				t.Errorf("PortList() = %v, want %v", got, tt.wantPorts)
+			}
+		})
+	}
+}
+
+func targetsTestView() *View {
+	open := nmap.State{State: "open"}
+	return NewNmapView(&nmap.Run{Hosts: []nmap.Host{
+		{
+			Status:    nmap.Status{State: "up"},
+			Addresses: []nmap.Address{{Addr: "10.0.0.1", AddrType: "ipv4"}, {Addr: "00:11:22:33:44:55", AddrType: "mac"}},
+			Hostnames: []nmap.Hostname{{Name: "web.example.com", Type: "user"}, {Name: "www.example.com", Type: "PTR"}},
+			Ports: []nmap.Port{
+				{ID: 22, Protocol: "tcp", State: open, Service: nmap.Service{Name: "ssh"}},
+				{ID: 53, Protocol: "udp", State: open, Service: nmap.Service{Name: "domain"}},
+			},
+		},
+		{
+			Status:    nmap.Status{State: "up"},
+			Addresses: []nmap.Address{{Addr: "10.0.0.2", AddrType: "ipv4"}},
+			Ports: []nmap.Port{
+				{ID: 22, Protocol: "tcp", State: open, Service: nmap.Service{Name: "ssh"}},
+				{ID: 53, Protocol: "udp", State: open, Service: nmap.Service{Name: "domain"}},
+				{ID: 8080, Protocol: "tcp", State: open, Service: nmap.Service{Name: "tcpwrapped"}},
+			},
+		},
+		{
+			Status:    nmap.Status{State: "up"},
+			Addresses: []nmap.Address{{Addr: "10.0.0.3", AddrType: "ipv4"}},
+			Ports: []nmap.Port{
+				{ID: 443, Protocol: "tcp", State: open, Service: nmap.Service{Name: "http", Tunnel: "ssl"}},
+				{ID: 2905, Protocol: "sctp", State: open, Service: nmap.Service{Name: "m3ua"}},
+			},
+		},
+	}})
+}
+
+func TestGetTargets(t *testing.T) {
+	tests := []struct {
+		name      string
+		hostnames bool
+		want      []string
+	}{
+		{
+			name: "addresses",
+			want: []string{
+				"10.0.0.1 22/tcp ssh",
+				"10.0.0.1 53/udp domain",
+				"10.0.0.2 22/tcp ssh",
+				"10.0.0.2 53/udp domain",
+				"10.0.0.3 443/tcp https",
+				"10.0.0.3 2905/sctp m3ua",
+			},
+		},
+		{
+			name:      "hostnames",
+			hostnames: true,
+			want: []string{
+				"10.0.0.1 22/tcp ssh",
+				"10.0.0.1 53/udp domain",
+				"10.0.0.2 22/tcp ssh",
+				"10.0.0.2 53/udp domain",
+				"10.0.0.3 443/tcp https",
+				"10.0.0.3 2905/sctp m3ua",
+				"web.example.com 22/tcp ssh",
+				"web.example.com 53/udp domain",
+				"www.example.com 22/tcp ssh",
+				"www.example.com 53/udp domain",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, target := range targetsTestView().GetTargets(0, tt.hostnames) {
+				got = append(got, fmt.Sprintf("%s %d/%s %s", target.Host, target.Port, target.Protocol, target.Service))
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GetTargets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteTargets(t *testing.T) {
+	targets := targetsTestView().GetTargets(0, false)[:2]
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{format: "hostport", want: "10.0.0.1:22\n10.0.0.1:53\n"},
+		{format: "ipport", want: "10.0.0.1 22\n10.0.0.1 53\n"},
+		{format: "url", want: "ssh://10.0.0.1:22\ndomain://10.0.0.1:53\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var out strings.Builder
+			err := WriteTargets(&out, tt.format, targets)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if out.String() != tt.want {
+				t.Errorf("WriteTargets() = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+
+	if err := WriteTargets(io.Discard, "csv", targets); err == nil {
+		t.Error("WriteTargets() error = nil, want an unknown format error")
+	}
+}
+
+func TestTargetFiles(t *testing.T) {
+	targets := targetsTestView().GetTargets(0, false)
+
+	tests := []struct {
+		name  string
+		files map[string][]string
+		want  map[string][]string
+	}{
+		{
+			name:  "msf",
+			files: MSFTargetFiles(targets),
+			want: map[string][]string{
+				"ssh_22_tcp.rhosts":     {"10.0.0.1", "10.0.0.2"},
+				"domain_53_udp.rhosts":  {"10.0.0.1", "10.0.0.2"},
+				"https_443_tcp.rhosts":  {"10.0.0.3"},
+				"m3ua_2905_sctp.rhosts": {"10.0.0.3"},
+			},
+		},
+		{
+			name:  "service-files",
+			files: ServiceTargetFiles(targets),
+			want: map[string][]string{
+				"ssh.txt":    {"10.0.0.1:22", "10.0.0.2:22"},
+				"domain.txt": {"10.0.0.1:53", "10.0.0.2:53"},
+				"https.txt":  {"10.0.0.3:443"},
+				"m3ua.txt":   {"10.0.0.3:2905"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !maps.EqualFunc(tt.files, tt.want, slices.Equal) {
+				t.Errorf("files = %v, want %v", tt.files, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetScanGroups(t *testing.T) {
+	tests := []struct {
+		name      string
+		hostnames bool
+		want      []string
+	}{
+		{
+			name: "addresses",
+			want: []string{
+				"10.0.0.1,10.0.0.2: -sS -sU -p T:22,U:53 -iL group.txt",
+				"10.0.0.3: -sS -sY -p T:443,S:2905 -iL group.txt",
+			},
+		},
+		{
+			name:      "hostnames",
+			hostnames: true,
+			want: []string{
+				"10.0.0.2,web.example.com: -sS -sU -p T:22,U:53 -iL group.txt",
+				"10.0.0.3: -sS -sY -p T:443,S:2905 -iL group.txt",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, group := range targetsTestView().GetScanGroups(0, tt.hostnames) {
+				got = append(got, strings.Join(group.Hosts, ",")+": "+strings.Join(group.NmapArgs("group.txt"), " "))
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GetScanGroups() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	tcpOnly := TargetGroup{Ports: []Target{{Port: 22, Protocol: "tcp"}, {Port: 80, Protocol: "tcp"}}}
+	if got := strings.Join(tcpOnly.NmapArgs("group.txt"), " "); got != "-p 22,80 -iL group.txt" {
+		t.Errorf("NmapArgs() = %q, want TCP ports without scan types", got)
+	}
+}
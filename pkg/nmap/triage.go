@@ -0,0 +1,331 @@
+package nmap
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+// Triage states of hosts and ports. Anything not triaged yet is unreviewed.
+const (
+	TriageUnreviewed    = "unreviewed"
+	TriageReviewed      = "reviewed"
+	TriageFalsePositive = "false-positive"
+	TriageExploited     = "exploited"
+	TriageOutOfScope    = "out-of-scope"
+)
+
+var triageStates = []string{TriageUnreviewed, TriageReviewed, TriageFalsePositive, TriageExploited, TriageOutOfScope}
+
+// TriageStates returns the valid triage states.
+func TriageStates() []string {
+	return slices.Clone(triageStates)
+}
+
+func checkTriageState(state string) error {
+	if !slices.Contains(triageStates, state) {
+		return fmt.Errorf("unknown triage state %q, expected one of: %s", state, strings.Join(triageStates, ", "))
+	}
+	return nil
+}
+
+// Note is a timestamped note on a host or port.
+type Note struct {
+	Text    string    `json:"text"`
+	Created time.Time `json:"created"`
+}
+
+// Annotation is the triage state, tags and notes of a host or port.
+type Annotation struct {
+	State   string    `json:"state,omitempty"`
+	Tags    []string  `json:"tags,omitempty"`
+	Notes   []Note    `json:"notes,omitempty"`
+	Updated time.Time `json:"updated"`
+}
+
+// HostAnnotation is the annotation of a host and its ports, keyed by
+// port/protocol like "22/tcp".
+type HostAnnotation struct {
+	Annotation
+	Ports map[string]*Annotation `json:"ports,omitempty"`
+}
+
+// Annotations are the annotations of hosts, keyed by IP or hostname. They
+// are kept in their own JSON file, nex-triage.json in the current directory
+// unless --triage-file is set, so they survive re-running and merging scans.
+type Annotations struct {
+	Hosts map[string]*HostAnnotation `json:"hosts"`
+
+	path string
+}
+
+// LoadAnnotations reads the annotations file. A missing file has no
+// annotations yet.
+func LoadAnnotations(path string) (*Annotations, error) {
+	annotations := &Annotations{
+		Hosts: map[string]*HostAnnotation{},
+		path:  path,
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return annotations, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, annotations)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse annotations %s: %w", path, err)
+	}
+
+	if annotations.Hosts == nil {
+		annotations.Hosts = map[string]*HostAnnotation{}
+	}
+	return annotations, nil
+}
+
+// Save writes the annotations back to the file they were loaded from.
+func (a *Annotations) Save() error {
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(a.path, append(data, '\n'), 0644)
+}
+
+// ParseTriageTarget parses a host, optionally with a port and protocol, like
+// "10.0.0.1", "web.example.com:443", "10.0.0.1:53/udp" or "[::1]:22". The
+// protocol defaults to tcp. The port is returned as "port/protocol", or
+// empty for just a host.
+func ParseTriageTarget(target string) (string, string, error) {
+	host := target
+	port := ""
+	protocol := ProtocolTCP
+
+	before, after, hasProtocol := strings.Cut(target, "/")
+	if hasProtocol {
+		target = before
+		protocol = strings.ToLower(after)
+	}
+
+	if strings.HasPrefix(target, "[") || strings.Count(target, ":") == 1 {
+		var err error
+		host, port, err = net.SplitHostPort(target)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid target %q: %w", target, err)
+		}
+	} else {
+		host = target
+	}
+
+	if host == "" {
+		return "", "", fmt.Errorf("invalid target %q: missing host", target)
+	}
+
+	if port == "" {
+		if target != host || hasProtocol {
+			return "", "", fmt.Errorf("invalid target %q: missing port", target)
+		}
+		return strings.ToLower(host), "", nil
+	}
+
+	id, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid port %q", port)
+	}
+
+	if !slices.Contains(protocols, protocol) {
+		return "", "", fmt.Errorf("unknown protocol %q", protocol)
+	}
+	return strings.ToLower(host), fmt.Sprintf("%d/%s", id, protocol), nil
+}
+
+// get returns the annotation of the host, or of the port if port is set,
+// creating it if needed, and marks it as updated.
+func (a *Annotations) get(host string, port string, now time.Time) *Annotation {
+	hostAnnotation, ok := a.Hosts[host]
+	if !ok {
+		hostAnnotation = &HostAnnotation{}
+		a.Hosts[host] = hostAnnotation
+	}
+
+	hostAnnotation.Updated = now
+	if port == "" {
+		return &hostAnnotation.Annotation
+	}
+
+	if hostAnnotation.Ports == nil {
+		hostAnnotation.Ports = map[string]*Annotation{}
+	}
+
+	annotation, ok := hostAnnotation.Ports[port]
+	if !ok {
+		annotation = &Annotation{}
+		hostAnnotation.Ports[port] = annotation
+	}
+
+	annotation.Updated = now
+	return annotation
+}
+
+// SetState sets the triage state of the target, as parsed by
+// ParseTriageTarget.
+func (a *Annotations) SetState(target string, state string, now time.Time) error {
+	err := checkTriageState(state)
+	if err != nil {
+		return err
+	}
+
+	host, port, err := ParseTriageTarget(target)
+	if err != nil {
+		return err
+	}
+
+	a.get(host, port, now).State = state
+	return nil
+}
+
+// AddNote adds a note and tags to the target and removes the untags from
+// it. Empty notes are not added.
+func (a *Annotations) AddNote(target string, text string, tags []string, untags []string, now time.Time) error {
+	host, port, err := ParseTriageTarget(target)
+	if err != nil {
+		return err
+	}
+
+	annotation := a.get(host, port, now)
+	if text != "" {
+		annotation.Notes = append(annotation.Notes, Note{Text: text, Created: now})
+	}
+
+	for _, tag := range tags {
+		if !slices.Contains(annotation.Tags, tag) {
+			annotation.Tags = append(annotation.Tags, tag)
+		}
+	}
+
+	annotation.Tags = slices.DeleteFunc(annotation.Tags, func(tag string) bool {
+		return slices.Contains(untags, tag)
+	})
+	slices.Sort(annotation.Tags)
+	return nil
+}
+
+// hostAnnotation returns the annotations of the host kept under any of its
+// addresses and hostnames merged together. The state under an address wins
+// over the state under a hostname.
+func (a *Annotations) hostAnnotation(h *nmap.Host) *HostAnnotation {
+	if a == nil {
+		return nil
+	}
+
+	var keys []string
+	for _, addr := range h.Addresses {
+		keys = append(keys, strings.ToLower(addr.Addr))
+	}
+
+	for _, hostname := range h.Hostnames {
+		keys = append(keys, strings.ToLower(hostname.Name))
+	}
+
+	var merged *HostAnnotation
+	for _, key := range keys {
+		hostAnnotation, ok := a.Hosts[key]
+		if !ok {
+			continue
+		}
+
+		if merged == nil {
+			merged = &HostAnnotation{Ports: map[string]*Annotation{}}
+		}
+
+		merged.merge(&hostAnnotation.Annotation)
+		for port, annotation := range hostAnnotation.Ports {
+			if merged.Ports[port] == nil {
+				merged.Ports[port] = &Annotation{}
+			}
+			merged.Ports[port].merge(annotation)
+		}
+	}
+	return merged
+}
+
+// merge adds the tags and notes of other, and its state if there is none.
+func (an *Annotation) merge(other *Annotation) {
+	an.State = cmp.Or(an.State, other.State)
+
+	for _, tag := range other.Tags {
+		if !slices.Contains(an.Tags, tag) {
+			an.Tags = append(an.Tags, tag)
+		}
+	}
+	slices.Sort(an.Tags)
+
+	an.Notes = append(an.Notes, other.Notes...)
+	slices.SortStableFunc(an.Notes, func(a, b Note) int {
+		return a.Created.Compare(b.Created)
+	})
+
+	if other.Updated.After(an.Updated) {
+		an.Updated = other.Updated
+	}
+}
+
+// portAnnotation returns the annotation of the port on the host.
+func (a *Annotations) portAnnotation(h *nmap.Host, port *nmap.Port) *Annotation {
+	hostAnnotation := a.hostAnnotation(h)
+	if hostAnnotation == nil {
+		return nil
+	}
+	return hostAnnotation.Ports[triagePortKey(port)]
+}
+
+// portState returns the triage state of the port, falling back to the state
+// of the host.
+func (a *Annotations) portState(h *nmap.Host, port *nmap.Port) string {
+	var hostState, portState string
+	if hostAnnotation := a.hostAnnotation(h); hostAnnotation != nil {
+		hostState = hostAnnotation.State
+	}
+
+	if annotation := a.portAnnotation(h, port); annotation != nil {
+		portState = annotation.State
+	}
+	return cmp.Or(portState, hostState, TriageUnreviewed)
+}
+
+func triagePortKey(port *nmap.Port) string {
+	key := newPortKey(*port)
+	return fmt.Sprintf("%d/%s", key.id, key.protocol)
+}
+
+// SetAnnotations sets the annotations shown by the triage and tags columns
+// and the JSON output.
+func (v *View) SetAnnotations(annotations *Annotations) {
+	v.annotations = annotations
+}
+
+// SetTriageFilter only keeps open ports in one of the triage states.
+func (v *View) SetTriageFilter(states []string) error {
+	for _, state := range states {
+		err := checkTriageState(state)
+		if err != nil {
+			return err
+		}
+	}
+
+	v.triageStates = states
+	return nil
+}
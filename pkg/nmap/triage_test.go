@@ -0,0 +1,128 @@
+package nmap
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+func TestParseTriageTarget(t *testing.T) {
+	tests := []struct {
+		target   string
+		wantHost string
+		wantPort string
+		wantErr  bool
+	}{
+		{target: "10.0.0.1", wantHost: "10.0.0.1"},
+		{target: "Web.Example.com:443", wantHost: "web.example.com", wantPort: "443/tcp"},
+		{target: "10.0.0.1:53/UDP", wantHost: "10.0.0.1", wantPort: "53/udp"},
+		{target: "2001:db8::1", wantHost: "2001:db8::1"},
+		{target: "[2001:db8::1]:22", wantHost: "2001:db8::1", wantPort: "22/tcp"},
+		{target: "10.0.0.0/24", wantErr: true},
+		{target: "10.0.0.1:", wantErr: true},
+		{target: "10.0.0.1:http", wantErr: true},
+		{target: "10.0.0.1:22/icmp", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			host, port, err := ParseTriageTarget(tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTriageTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if host != tt.wantHost || port != tt.wantPort {
+				t.Errorf("ParseTriageTarget() = %q %q, want %q %q", host, port, tt.wantHost, tt.wantPort)
+			}
+		})
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "triage.json")
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	annotations, err := LoadAnnotations(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []func() error{
+		func() error { return annotations.SetState("10.0.0.1:21", TriageExploited, now) },
+		func() error { return annotations.SetState("8.8.8.8", TriageOutOfScope, now) },
+		func() error { return annotations.SetState("8.8.8.8:6380", TriageReviewed, now) },
+		func() error { return annotations.AddNote("10.0.0.1", "", []string{"dmz", "old"}, nil, now) },
+		func() error {
+			return annotations.AddNote("files.example.com:445", "guest access", []string{"smb"}, nil, now)
+		},
+		func() error { return annotations.AddNote("10.0.0.1", "", nil, []string{"old"}, now) },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := annotations.SetState("10.0.0.1", "done", now); err == nil {
+		t.Error("SetState() error = nil, want an unknown state error")
+	}
+
+	err = annotations.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	annotations, err = LoadAnnotations(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := rulesTestRun()
+	run.Hosts[0].Hostnames = []nmap.Hostname{{Name: "files.example.com"}}
+
+	view := NewNmapView(run)
+	view.SetAnnotations(annotations)
+
+	columns := []struct {
+		column string
+		want   [][]string
+	}{
+		{
+			column: "triage",
+			want: [][]string{
+				{"21/tcp: exploited", "445/tcp: unreviewed"},
+				{"host: out-of-scope", "445/tcp: out-of-scope", "6380/tcp: reviewed", "2323/tcp: out-of-scope"},
+			},
+		},
+		{
+			column: "tags",
+			want: [][]string{
+				{"host: dmz", "445/tcp: smb"},
+				nil,
+			},
+		},
+	}
+	for _, tt := range columns {
+		for i, h := range view.GetHosts() {
+			if got := viewColumns[tt.column].value(view, h); !slices.Equal(got, tt.want[i]) {
+				t.Errorf("%s column of host %d = %v, want %v", tt.column, i, got, tt.want[i])
+			}
+		}
+	}
+
+	err = view.SetTriageFilter([]string{TriageUnreviewed, TriageReviewed})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []int
+	for _, h := range view.GetHostsWithOptions(ViewOpenPorts) {
+		got = append(got, portIDs(*h)...)
+	}
+
+	if want := []int{445, 6380}; !slices.Equal(got, want) {
+		t.Errorf("GetHostsWithOptions() ports = %v, want %v", got, want)
+	}
+}
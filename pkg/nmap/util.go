@@ -3,8 +3,10 @@ package nmap
 import (
 	"github.com/Ullaakut/nmap/v2"
 	"github.com/analog-substance/arsenic/pkg/host"
+	"net"
 	"os"
 	"path/filepath"
+	"slices"
 )
 
 func getHost(hostnames []string, ips []string) (*host.Host, error) {
@@ -48,3 +50,68 @@ func portIsOpen(port *nmap.Port) bool {
 //		log.Printf("%s took %v\n", name, time.Since(start))
 //	}
 //}
+
+func hostnamesAndIPs(h *nmap.Host) ([]string, []string) {
+	hostnames := []string{}
+	ips := []string{}
+
+	for _, ip := range h.Addresses {
+		ips = append(ips, ip.String())
+	}
+	for _, hostname := range h.Hostnames {
+		hostnames = append(hostnames, hostname.Name)
+	}
+	return hostnames, ips
+}
+
+// addressKinds reports whether the host has private and public IP addresses.
+func addressKinds(h *nmap.Host) (bool, bool) {
+	hasPrivateIPs := false
+	hasPublicIPs := false
+
+	for _, addr := range h.Addresses {
+		ip := net.ParseIP(addr.Addr)
+		if ip == nil {
+			continue
+		}
+
+		if ip.IsPrivate() {
+			hasPrivateIPs = true
+		} else {
+			hasPublicIPs = true
+		}
+	}
+	return hasPrivateIPs, hasPublicIPs
+}
+
+// HostListFilter returns a host filter that drops hosts matching any of the
+// excluded hostnames or IPs and, if include is not empty, keeps only hosts
+// matching one of the included ones.
+func HostListFilter(include []string, exclude []string) func(hostnames []string, ips []string) bool {
+	return func(hostnames []string, ips []string) bool {
+		for _, exclude := range exclude {
+			if slices.Contains(hostnames, exclude) {
+				return false
+			}
+
+			if slices.Contains(ips, exclude) {
+				return false
+			}
+		}
+
+		if len(include) > 0 {
+			for _, include := range include {
+				if slices.Contains(hostnames, include) {
+					return true
+				}
+
+				if slices.Contains(ips, include) {
+					return true
+				}
+			}
+			return false
+		}
+
+		return true
+	}
+}
@@ -0,0 +1,155 @@
+package nmap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// versionSegments splits a version like "7.4p1" into ["7", "4", "p", "1"].
+// Anything after the first space, like distribution patch levels, is
+// ignored.
+func versionSegments(version string) []string {
+	fields := strings.Fields(version)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	var segments []string
+	current := ""
+	currentIsDigit := false
+	for _, r := range fields[0] {
+		isDigit := unicode.IsDigit(r)
+		if !isDigit && !unicode.IsLetter(r) {
+			if current != "" {
+				segments = append(segments, current)
+			}
+			current = ""
+			continue
+		}
+
+		if current != "" && isDigit != currentIsDigit {
+			segments = append(segments, current)
+			current = ""
+		}
+		current += string(r)
+		currentIsDigit = isDigit
+	}
+
+	if current != "" {
+		segments = append(segments, current)
+	}
+	return segments
+}
+
+// CompareVersions compares two version strings segment by segment, comparing
+// numeric segments as numbers. It returns -1, 0 or 1 like strings.Compare.
+func CompareVersions(a string, b string) int {
+	aSegments := versionSegments(a)
+	bSegments := versionSegments(b)
+
+	for i := 0; i < len(aSegments) && i < len(bSegments); i++ {
+		aNum, aErr := strconv.Atoi(aSegments[i])
+		bNum, bErr := strconv.Atoi(bSegments[i])
+
+		var c int
+		switch {
+		case aErr == nil && bErr == nil:
+			c = aNum - bNum
+		case aErr == nil:
+			// 1.0.1 is newer than 1.0rc1
+			c = 1
+		case bErr == nil:
+			c = -1
+		default:
+			c = strings.Compare(strings.ToLower(aSegments[i]), strings.ToLower(bSegments[i]))
+		}
+
+		if c < 0 {
+			return -1
+		} else if c > 0 {
+			return 1
+		}
+	}
+
+	switch {
+	case len(aSegments) < len(bSegments):
+		return -1
+	case len(aSegments) > len(bSegments):
+		return 1
+	}
+	return 0
+}
+
+type versionConstraint struct {
+	op      string
+	version string
+}
+
+func (c versionConstraint) matches(version string) bool {
+	cmp := CompareVersions(version, c.version)
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "!=":
+		return cmp != 0
+	}
+	return cmp == 0
+}
+
+// VersionConstraints is a list of version constraints that all need to match.
+type VersionConstraints []versionConstraint
+
+// ParseVersionConstraints parses comma separated constraints like
+// ">=2.4.0,<2.4.50". A version without an operator has to match exactly.
+func ParseVersionConstraints(constraints string) (VersionConstraints, error) {
+	var parsed VersionConstraints
+	for _, constraint := range strings.Split(constraints, ",") {
+		constraint = strings.TrimSpace(constraint)
+		if constraint == "" {
+			continue
+		}
+
+		op := "="
+		for _, prefix := range []string{"<=", ">=", "!=", "==", "<", ">", "="} {
+			if strings.HasPrefix(constraint, prefix) {
+				op = prefix
+				constraint = strings.TrimSpace(strings.TrimPrefix(constraint, prefix))
+				break
+			}
+		}
+
+		if constraint == "" {
+			return nil, fmt.Errorf("missing version in constraint %q", constraints)
+		}
+
+		parsed = append(parsed, versionConstraint{op: op, version: constraint})
+	}
+	return parsed, nil
+}
+
+// Matches reports whether the version satisfies every constraint. Unknown
+// versions never match a non-empty list of constraints.
+func (c VersionConstraints) Matches(version string) bool {
+	if len(c) == 0 {
+		return true
+	}
+
+	if version == "" {
+		return false
+	}
+
+	for _, constraint := range c {
+		if !constraint.matches(version) {
+			return false
+		}
+	}
+	return true
+}
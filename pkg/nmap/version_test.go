@@ -0,0 +1,55 @@
+package nmap
+
+import (
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{a: "1.18.0", b: "1.18.0", want: 0},
+		{a: "1.9", b: "1.18", want: -1},
+		{a: "7.4p1", b: "7.4", want: 1},
+		{a: "8.2p1 Ubuntu 4ubuntu0.5", b: "8.0", want: 1},
+		{a: "2.4.49", b: "2.4.50", want: -1},
+		{a: "1.0.1", b: "1.0rc1", want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			if got := CompareVersions(tt.a, tt.b); got != tt.want {
+				t.Errorf("CompareVersions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionConstraintsMatches(t *testing.T) {
+	tests := []struct {
+		constraints string
+		version     string
+		want        bool
+	}{
+		{constraints: ">=2.4.0,<2.4.50", version: "2.4.49", want: true},
+		{constraints: ">=2.4.0,<2.4.50", version: "2.4.50", want: false},
+		{constraints: "<8.0", version: "7.4p1", want: true},
+		{constraints: "1.18.0", version: "1.18.0", want: true},
+		{constraints: "!=1.18.0", version: "1.18.0", want: false},
+		{constraints: "<8.0", version: "", want: false},
+		{constraints: "", version: "", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraints+" "+tt.version, func(t *testing.T) {
+			constraints, err := ParseVersionConstraints(tt.constraints)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := constraints.Matches(tt.version); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
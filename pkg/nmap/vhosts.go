@@ -0,0 +1,93 @@
+package nmap
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/Ullaakut/nmap/v2"
+	"github.com/analog-substance/nex/pkg/dns_guard_rail"
+)
+
+// Evidence recorded for the names used to build URLs.
+const (
+	EvidenceAddress      = "address"
+	EvidenceHostname     = "hostname"
+	EvidenceSSLCert      = "ssl-cert"
+	EvidenceHTTPRedirect = "http-redirect"
+	EvidenceDNSRecord    = "dns-record"
+)
+
+// virtualHost is a name a host may be reachable by and how it was found.
+type virtualHost struct {
+	name     string
+	evidence string
+}
+
+// virtualHosts returns the hostnames nmap recorded for the host and the
+// names from DNS records along with names found in certificate SANs and HTTP
+// redirects. Names the DNS guard rail doesn't want investigated are left out.
+func virtualHosts(h *nmap.Host, dnsNames []DNSName) []virtualHost {
+	var vhosts []virtualHost
+	seen := map[string]bool{}
+	add := func(name string, evidence string) {
+		name = strings.ToLower(strings.TrimSuffix(name, "."))
+		if name == "" || strings.Contains(name, "*") || net.ParseIP(name) != nil {
+			return
+		}
+
+		if !dns_guard_rail.ShouldInvestigateMore(name) {
+			// Don't care....
+			return
+		}
+
+		key := name + "\x00" + evidence
+		if !seen[key] {
+			seen[key] = true
+			vhosts = append(vhosts, virtualHost{name: name, evidence: evidence})
+		}
+	}
+
+	for _, hostname := range h.Hostnames {
+		add(hostname.Name, fmt.Sprintf("%s:%s", EvidenceHostname, hostname.Type))
+	}
+
+	for _, dnsName := range dnsNames {
+		add(dnsName.Name, fmt.Sprintf("%s:%s", EvidenceDNSRecord, strings.Join(dnsName.Sources, ",")))
+	}
+
+	for _, port := range h.Ports {
+		for _, script := range port.Scripts {
+			switch script.ID {
+			case "ssl-cert":
+				for _, name := range certNames(script) {
+					add(name, fmt.Sprintf("%s:%d/%s", EvidenceSSLCert, port.ID, port.Protocol))
+				}
+			case "http-title":
+				add(redirectHost(script), fmt.Sprintf("%s:%d/%s", EvidenceHTTPRedirect, port.ID, port.Protocol))
+			}
+		}
+	}
+
+	return vhosts
+}
+
+// certNames returns the DNS names from the subject alternative name
+// extension of an ssl-cert script.
+func certNames(script nmap.Script) []string {
+	cert, err := ParseSSLCert(script)
+	if err != nil {
+		return nil
+	}
+	return cert.DNSNames()
+}
+
+// redirectHost returns the host of the redirect http-title did not follow.
+func redirectHost(script nmap.Script) string {
+	u, err := url.Parse(ParseHTTPTitle(script).RedirectURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
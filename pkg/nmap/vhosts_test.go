@@ -0,0 +1,63 @@
+package nmap
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+func TestVirtualHosts(t *testing.T) {
+	h := &nmap.Host{
+		Hostnames: []nmap.Hostname{{Name: "web.example.com", Type: "PTR"}},
+		Ports: []nmap.Port{
+			{
+				ID:       443,
+				Protocol: "tcp",
+				Scripts: []nmap.Script{{
+					ID: "ssl-cert",
+					Tables: []nmap.Table{{
+						Key: "extensions",
+						Tables: []nmap.Table{{
+							Elements: []nmap.Element{
+								{Key: "name", Value: "X509v3 Subject Alternative Name"},
+								{Key: "value", Value: "DNS:app.example.com, DNS:*.example.com, IP Address:10.0.0.1"},
+							},
+						}},
+					}},
+				}},
+			},
+			{
+				ID:       8443,
+				Protocol: "tcp",
+				Scripts: []nmap.Script{{
+					ID:     "ssl-cert",
+					Output: "Subject: commonName=api.example.com\nSubject Alternative Name: DNS:api.example.com, DNS:x.cloudfront.net\n",
+				}},
+			},
+			{
+				ID:       80,
+				Protocol: "tcp",
+				Scripts: []nmap.Script{{
+					ID:     "http-title",
+					Output: "Did not follow redirect to https://portal.example.com/login?a=1",
+				}},
+			},
+		},
+	}
+
+	var got []string
+	for _, vhost := range virtualHosts(h, nil) {
+		got = append(got, vhost.name+" "+vhost.evidence)
+	}
+
+	want := []string{
+		"web.example.com hostname:PTR",
+		"app.example.com ssl-cert:443/tcp",
+		"api.example.com ssl-cert:8443/tcp",
+		"portal.example.com http-redirect:80/tcp",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("virtualHosts() = %v, want %v", got, want)
+	}
+}
@@ -5,13 +5,11 @@ import (
 	"fmt"
 	"github.com/Ullaakut/nmap/v2"
 	"github.com/analog-substance/nex/pkg/dns_guard_rail"
-	"github.com/analog-substance/util/set"
-	"github.com/charmbracelet/lipgloss"
-	"github.com/charmbracelet/lipgloss/table"
+	"io"
 	"log"
+	"maps"
 	"net"
 	"os"
-	"regexp"
 	"slices"
 	"sort"
 	"strings"
@@ -30,19 +28,33 @@ const (
 )
 
 type View struct {
-	run          *nmap.Run
-	filter       func(hostnames []string, ips []string) bool
-	hosts        []*nmap.Host
-	excludePorts []int
-	includePorts []int
+	run           *nmap.Run
+	filter        func(hostnames []string, ips []string) bool
+	hosts         []*nmap.Host
+	excludePorts  []int
+	includePorts  []int
+	schemes       map[string]string
+	customSchemes map[string]string
+	columns       []string
+	cveIndex      *CVEIndex
+	annotations   *Annotations
+	triageStates  []string
+	assets        *AssetInventory
+	geoIP         *GeoIP
+	dnsRecords    *DNSRecords
+	where         []WhereCondition
+	out           io.Writer
 }
 
 func NewNmapView(run *nmap.Run) *View {
 	return &View{
-		run:          run,
-		filter:       defaultFilter,
-		excludePorts: []int{},
-		includePorts: []int{},
+		run:           run,
+		filter:        defaultFilter,
+		excludePorts:  []int{},
+		includePorts:  []int{},
+		schemes:       maps.Clone(DefaultSchemes),
+		customSchemes: map[string]string{},
+		out:           os.Stdout,
 	}
 }
 
@@ -62,20 +74,25 @@ func (v *View) SetIncludePorts(ports []int) {
 	v.includePorts = ports
 }
 
+// SetCVEIndex sets the index used to match services to CVEs in the JSON
+// output and GetVulns.
+func (v *View) SetCVEIndex(index *CVEIndex) {
+	v.cveIndex = index
+}
+
+// SetOutput sets where the Print functions write to. Defaults to os.Stdout.
+func (v *View) SetOutput(out io.Writer) {
+	v.out = out
+}
+
 func (v *View) GetHosts() []*nmap.Host {
 	if v.hosts == nil {
 		v.hosts = []*nmap.Host{}
 		for _, host := range v.run.Hosts {
 			if v.filter != nil {
-
-				hostnames := []string{}
-				ips := []string{}
-
-				for _, ip := range host.Addresses {
-					ips = append(ips, ip.String())
-				}
-				for _, hostname := range host.Hostnames {
-					hostnames = append(hostnames, hostname.Name)
+				hostnames, ips := hostnamesAndIPs(&host)
+				for _, dnsName := range v.dnsRecords.hostNames(&host) {
+					hostnames = append(hostnames, dnsName.Name)
 				}
 
 				if v.filter(hostnames, ips) {
@@ -88,44 +105,63 @@ func (v *View) GetHosts() []*nmap.Host {
 	return v.hosts
 }
 
-func (v *View) GetURLs(prefix string, options ViewOptions) []string {
+// URL is a URL built from the scan data along with the evidence for the
+// host name it uses.
+type URL struct {
+	URL      string   `json:"url"`
+	Host     string   `json:"host"`
+	Port     uint16   `json:"port"`
+	Evidence []string `json:"evidence"`
+}
 
-	urlSet := set.NewStringSet()
-	httpProtocolRe := regexp.MustCompile(`^https?`)
+func (v *View) GetURLs(prefix string, options ViewOptions) []string {
+	var urls []string
+	for _, u := range v.GetURLRecords(prefix, options) {
+		urls = append(urls, u.URL)
+	}
+	return urls
+}
 
-	for _, host := range v.GetHostsWithOptions(options) {
-		for _, port := range host.Ports {
+// GetURLRecords returns the URLs for the open ports of the matching hosts.
+// HTTP URLs are also built for every virtual host name that was found, so
+// virtual hosting can be tested.
+func (v *View) GetURLRecords(prefix string, options ViewOptions) []URL {
+	urlMap := map[string]*URL{}
+	addURL := func(proto string, host string, urlPort string, port uint16, evidence string) {
+		u := fmt.Sprintf("%s%s%s", proto, host, urlPort)
+		record, ok := urlMap[u]
+		if !ok {
+			record = &URL{URL: u, Host: host, Port: port}
+			urlMap[u] = record
+		}
 
-			// check to see if this port should be ignored
-			if slices.Contains(v.excludePorts, int(port.ID)) {
-				continue
-			}
+		if !slices.Contains(record.Evidence, evidence) {
+			record.Evidence = append(record.Evidence, evidence)
+		}
+	}
 
-			// check to see if this port should be included
-			if len(v.includePorts) > 0 && !slices.Contains(v.includePorts, int(port.ID)) {
-				continue
-			}
+	unknownSchemes := 0
+	// only open ports can have a URL and tcpwrapped ports never speak HTTP
+	for _, host := range v.GetHostsWithOptions(options | ViewOpenPorts | IgnoreTCPWrapped) {
+		dnsNames := v.dnsRecords.hostNames(host)
+		vhosts := virtualHosts(host, dnsNames)
 
-			if port.Service.Name == "tcpwrapped" {
+		for _, port := range host.Ports {
+			// IP protocol scans report protocol numbers, not ports
+			if strings.EqualFold(port.Protocol, ProtocolIP) {
 				continue
 			}
 
-			if !portIsOpen(&port) {
+			// nmap reports ports it couldn't identify as unknown, which is
+			// common enough to only count them
+			proto := v.urlScheme(&port)
+			if proto == "" {
+				unknownSchemes++
 				continue
 			}
 
-			proto := port.Service.Name
-
-			if port.ID == 443 {
-				proto = "https"
-			} else if port.ID == 80 {
-				proto = "http"
-			} else if httpProtocolRe.MatchString(proto) {
-				proto = httpProtocolRe.FindString(proto)
-			}
-
 			urlPort := fmt.Sprintf(":%d", port.ID)
-			if proto == "http" && port.ID == 80 || proto == "https" && port.ID == 443 {
+			if defaultSchemePorts[proto] == port.ID {
 				urlPort = ""
 			}
 
@@ -141,125 +177,152 @@ func (v *View) GetURLs(prefix string, options ViewOptions) []string {
 				}
 			}
 
-			if proto == "" {
-				bytes, err := json.Marshal(port)
-				if err == nil {
-					log.Println("empty protocol", string(bytes))
+			for _, dnsName := range dnsNames {
+				if dns_guard_rail.IsCDN(dnsName.Name) {
+					isCDN = true
+					break
 				}
-			} else {
-				proto = fmt.Sprintf("%s://", proto)
 			}
 
+			isHTTP := strings.HasPrefix(proto, "http")
+			proto = fmt.Sprintf("%s://", proto)
+
 			if !isCDN {
 				// not a CDN? add the IP addresses
 				for _, addr := range host.Addresses {
-					urlSet.Add(fmt.Sprintf("%s%s%s", proto, addr.Addr, urlPort))
+					addURL(proto, addr.Addr, urlPort, port.ID, EvidenceAddress)
 				}
 			}
 
-			if strings.HasPrefix(proto, "http") {
+			if isHTTP {
 				// HTTP eh? add other hostnames so we can test virtual hosting
-				for _, hostname := range host.Hostnames {
-					if !dns_guard_rail.ShouldInvestigateMore(hostname.Name) {
-						// Don't care....
-						continue
-					}
-
-					urlSet.Add(fmt.Sprintf("%s%s%s", proto, hostname.Name, urlPort))
+				for _, vhost := range vhosts {
+					addURL(proto, vhost.name, urlPort, port.ID, vhost.evidence)
 				}
 			}
 		}
 	}
 
-	return urlSet.StringSlice()
+	if unknownSchemes > 0 {
+		log.Printf("[!] Skipped %d open ports without a known URL scheme", unknownSchemes)
+	}
+
+	var urls []URL
+	for _, u := range urlMap {
+		urls = append(urls, *u)
+	}
+	slices.SortFunc(urls, func(a, b URL) int {
+		return strings.Compare(a.URL, b.URL)
+	})
+	return urls
 }
 
+// GetHostsWithOptions returns copies of the hosts matching the view filters.
+// Port filters are applied to each host as well, so every output built from
+// these hosts shows the same ports.
 func (v *View) GetHostsWithOptions(options ViewOptions) []*nmap.Host {
 	hosts := v.GetHosts()
 	returnHosts := []*nmap.Host{}
 	for _, h := range hosts {
-
-		hasPrivateIPs := false
-		hasPublicIPs := false
-
-		for _, addr := range h.Addresses {
-			ip := net.ParseIP(addr.Addr)
-			if ip == nil {
-				continue
-			}
-			isPrivate := ip.IsPrivate()
-
-			if isPrivate {
-				hasPrivateIPs = true
-			} else {
-				hasPublicIPs = true
-			}
-		}
-
-		// we want private IPs, but this host doesnt have any, skip it
-		if options&ViewPrivate != 0 && !hasPrivateIPs {
-			continue
-		}
-
-		// we want public IPs, but this host doesnt have any, skip it
-		if options&ViewPublic != 0 && !hasPublicIPs {
+		if !v.keepsHost(h, options) {
 			continue
 		}
 
 		hostHasOpenPorts := hasOpenPorts(h)
 
-		// we want up hosts and this host is not up
-		if options&ViewAliveHosts != 0 && h.Status.State != "up" && !hostHasOpenPorts {
-			continue
-		}
-
 		// we want open ports
 		if options&ViewOpenPorts != 0 && !hostHasOpenPorts {
 			continue
 		}
 
-		// Skip hosts that only have excluded ports open
-		if len(v.excludePorts) > 0 && v.hostOnlyHasExcludedPorts(h) {
+		// Skip hosts that do not have port that we want
+		if len(v.includePorts) > 0 && !portsContains(h.Ports, v.includePorts) {
 			continue
 		}
 
-		// Skip hosts that do not have port that we want
-		if len(v.includePorts) > 0 && !portsContains(h.Ports, v.includePorts) {
+		host := *h
+		host.Ports = v.filterPorts(h, options)
+
+		// Skip hosts that only have filtered out ports open
+		if hostHasOpenPorts && !hasOpenPorts(&host) {
 			continue
 		}
 
-		returnHosts = append(returnHosts, h)
+		returnHosts = append(returnHosts, &host)
 	}
 
 	return returnHosts
 }
 
-func (v *View) hostOnlyHasExcludedPorts(host *nmap.Host) bool {
-	if len(host.Ports) == 0 {
+// keepsHost reports whether the host matches the view's host filters,
+// whatever its ports.
+func (v *View) keepsHost(h *nmap.Host, options ViewOptions) bool {
+	hasPrivateIPs, hasPublicIPs := addressKinds(h)
+
+	// we want private IPs, but this host doesnt have any, skip it
+	if options&ViewPrivate != 0 && !hasPrivateIPs {
 		return false
 	}
 
-	for _, port := range host.Ports {
-		if port.State.State == "open" {
-			portID := int(port.ID)
-			if !slices.Contains(v.excludePorts, portID) {
-				return false
-			}
-		}
+	// we want public IPs, but this host doesnt have any, skip it
+	if options&ViewPublic != 0 && !hasPublicIPs {
+		return false
 	}
 
-	hasOpenExcludedPort := false
-	for _, port := range host.Ports {
-		if port.State.State == "open" {
-			portID := int(port.ID)
-			if slices.Contains(v.excludePorts, portID) {
-				hasOpenExcludedPort = true
-				break
-			}
+	// we want up hosts and this host is not up
+	if options&ViewAliveHosts != 0 && h.Status.State != "up" && !hasOpenPorts(h) {
+		return false
+	}
+
+	return v.matchesWhere(h)
+}
+
+// GetRun returns a copy of the scan run with only the hosts and ports that
+// match the view filters, ready to be written back to nmap XML.
+func (v *View) GetRun(options ViewOptions) (*nmap.Run, error) {
+	run := newXMLRun(v.run)
+	for _, h := range v.GetHostsWithOptions(options) {
+		host := *h
+		host.Ports = withoutNexScripts(host.Ports)
+		run.Hosts = append(run.Hosts, host)
+	}
+
+	return rebuildRun(run)
+}
+
+// filterPorts returns the ports of the host that match the view's port
+// filters.
+func (v *View) filterPorts(h *nmap.Host, options ViewOptions) []nmap.Port {
+	var filtered []nmap.Port
+	for _, port := range h.Ports {
+		if !v.keepsPortID(port.ID) {
+			continue
+		}
+
+		if options&IgnoreTCPWrapped != 0 && port.Service.Name == "tcpwrapped" {
+			continue
 		}
+
+		if options&ViewOpenPorts != 0 && !portIsOpen(&port) {
+			continue
+		}
+
+		if len(v.triageStates) > 0 && portIsOpen(&port) && !slices.Contains(v.triageStates, v.annotations.portState(h, &port)) {
+			continue
+		}
+
+		filtered = append(filtered, port)
 	}
+	return filtered
+}
 
-	return hasOpenExcludedPort
+// keepsPortID reports whether the port number matches the view's include
+// and exclude port lists.
+func (v *View) keepsPortID(id uint16) bool {
+	if slices.Contains(v.excludePorts, int(id)) {
+		return false
+	}
+	return len(v.includePorts) == 0 || slices.Contains(v.includePorts, int(id))
 }
 
 func portsContains(hostPorts []nmap.Port, portsToCheck []int) bool {
@@ -274,12 +337,16 @@ func portsContains(hostPorts []nmap.Port, portsToCheck []int) bool {
 }
 
 func (v *View) PrintJSON(options ViewOptions) error {
-	hosts := v.GetHostsWithOptions(options)
+	var hosts []HostOutput
+	for _, h := range v.GetHostsWithOptions(options) {
+		hosts = append(hosts, v.hostOutput(h))
+	}
+
 	output, err := json.MarshalIndent(hosts, "", "  ")
 	if err != nil {
 		return err
 	}
-	fmt.Println(string(output))
+	fmt.Fprintln(v.out, string(output))
 	return nil
 }
 
@@ -310,27 +377,42 @@ func (v *View) PrintList(options ViewOptions) {
 		hostSlice = append(hostSlice, host)
 	}
 	sort.Strings(hostSlice)
-	fmt.Println(strings.Join(hostSlice, "\n"))
+	fmt.Fprintln(v.out, strings.Join(hostSlice, "\n"))
 }
 
 func (v *View) PrintTable(sortByArg string, options ViewOptions) {
+	headers, data := v.tableRows(sortByArg, options)
+	RenderTable(v.out, headers, data)
+}
 
-	re := lipgloss.NewRenderer(os.Stdout)
-	baseStyle := re.NewStyle().Padding(0, 1)
-	headerStyle := baseStyle.Foreground(lipgloss.Color("252")).Bold(true)
+// PrintCSV prints the table shown by PrintTable as CSV.
+func (v *View) PrintCSV(sortByArg string, options ViewOptions) error {
+	headers, data := v.tableRows(sortByArg, options)
+	return WriteCSV(v.out, headers, data)
+}
+
+// tableRows returns the headers and sorted rows shown by PrintTable.
+func (v *View) tableRows(sortByArg string, options ViewOptions) ([]string, [][]string) {
+	portColumnWidth := 50
+	data := [][]string{}
+	var headers = []string{"IP", "Hostnames"}
 
-	CapitalizeHeaders := func(data []string) []string {
-		for i := range data {
-			data[i] = strings.ToUpper(data[i])
+	// TCP and UDP columns are always shown, SCTP and IP only when scanned
+	hosts := v.GetHostsWithOptions(options)
+	columnProtocols := []string{ProtocolTCP, ProtocolUDP}
+	for _, protocol := range []string{ProtocolSCTP, ProtocolIP} {
+		if hostsHaveProtocol(hosts, protocol) {
+			columnProtocols = append(columnProtocols, protocol)
 		}
-		return data
+	}
+	for _, protocol := range columnProtocols {
+		headers = append(headers, strings.ToUpper(protocol))
+	}
+	for _, name := range v.columns {
+		headers = append(headers, v.column(name).header)
 	}
 
-	ignoreTCPWrapped := options&IgnoreTCPWrapped != 0
-	portColumnWidth := 50
-	data := [][]string{}
-	var headers = []string{"IP", "Hostnames", "TCP", "UDP"}
-	for _, h := range v.GetHostsWithOptions(options) {
+	for _, h := range hosts {
 		hasPrivate := false
 		hasPublic := false
 
@@ -356,45 +438,35 @@ func (v *View) PrintTable(sortByArg string, options ViewOptions) {
 		}
 		sort.Strings(hostnames)
 
-		var tcp []int
-		var udp []int
+		openPorts := map[string][]int{}
 		for _, p := range h.Ports {
 			if portIsOpen(&p) {
-				port := int(p.ID)
-
-				if slices.Contains(v.excludePorts, port) {
-					continue
-				}
-
-				if strings.EqualFold(p.Protocol, "tcp") {
-					if !ignoreTCPWrapped || p.Service.Name != "tcpwrapped" {
-						tcp = append(tcp, port)
-					}
-				} else {
-					udp = append(udp, port)
-				}
+				protocol := strings.ToLower(p.Protocol)
+				openPorts[protocol] = append(openPorts[protocol], int(p.ID))
 			}
 		}
 
-		if ignoreTCPWrapped && len(tcp) == 0 && len(udp) == 0 {
-			// this will still need to be done for the other views :(
-			// need to think of a better way to filter this data in the nmap run
-			// or view filter
+		// the table only lists open ports, so hosts without any are left out
+		// when tcpwrapped ports are hidden
+		if options&IgnoreTCPWrapped != 0 && len(openPorts) == 0 {
 			continue
 		}
 
-		sort.Ints(tcp)
-		sort.Ints(udp)
-
 		ipAddrsStr := strings.Join(ipAddrs, "\n")
 		hostnamesStr := strings.Join(hostnames, "\n")
 
-		tcpPorts := wrapPorts(tcp, portColumnWidth)
-		udpPorts := wrapPorts(udp, portColumnWidth)
+		row := []string{ipAddrsStr, hostnamesStr}
+		for _, protocol := range columnProtocols {
+			ports := openPorts[protocol]
+			sort.Ints(ports)
+			row = append(row, wrapPorts(ports, portColumnWidth))
+		}
+
+		for _, name := range v.columns {
+			row = append(row, strings.Join(v.column(name).value(v, h), "\n"))
+		}
 
-		data = append(data, []string{
-			ipAddrsStr, hostnamesStr, tcpPorts, udpPorts,
-		})
+		data = append(data, row)
 	}
 
 	parts := strings.Split(sortByArg, ";")
@@ -421,25 +493,18 @@ func (v *View) PrintTable(sortByArg string, options ViewOptions) {
 		}
 	})
 
-	ct := table.New().
-		Border(lipgloss.NormalBorder()).
-		BorderStyle(re.NewStyle().Foreground(lipgloss.Color("238"))).
-		Headers(CapitalizeHeaders(headers)...).
-		Rows(data...).
-		StyleFunc(func(row, col int) lipgloss.Style {
-			if row == 0 {
-				return headerStyle
-			}
-
-			even := row%2 == 0
+	return headers, data
+}
 
-			if even {
-				return baseStyle.Foreground(lipgloss.Color("245"))
+func hostsHaveProtocol(hosts []*nmap.Host, protocol string) bool {
+	for _, h := range hosts {
+		for _, p := range h.Ports {
+			if portIsOpen(&p) && strings.EqualFold(p.Protocol, protocol) {
+				return true
 			}
-			return baseStyle.Foreground(lipgloss.Color("252"))
-		})
-
-	fmt.Println(ct)
+		}
+	}
+	return false
 }
 
 func wrapPorts(ports []int, portColumnWidth int) string {
@@ -0,0 +1,337 @@
+package nmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+const viewTestXML = `<?xml version="1.0" encoding="UTF-8"?>
+<nmaprun scanner="nmap" args="nmap" start="1000" version="7.94" xmloutputversion="1.05">
+<host><status state="up" reason="syn-ack"/>
+<address addr="10.0.0.1" addrtype="ipv4"/>
+<hostnames><hostname name="web.example.com" type="PTR"/></hostnames>
+<ports>
+<port protocol="tcp" portid="22"><state state="open" reason="syn-ack"/><service name="ssh" method="probed" conf="10"/></port>
+<port protocol="tcp" portid="25"><state state="closed" reason="reset"/><service name="smtp" method="table" conf="3"/></port>
+<port protocol="tcp" portid="80"><state state="open" reason="syn-ack"/><service name="http" method="probed" conf="10"/></port>
+<port protocol="tcp" portid="445"><state state="open" reason="syn-ack"/><service name="tcpwrapped" method="probed" conf="8"/></port>
+</ports>
+</host>
+<host><status state="up" reason="syn-ack"/>
+<address addr="8.8.8.8" addrtype="ipv4"/>
+<ports>
+<port protocol="tcp" portid="8443"><state state="open" reason="syn-ack"/><service name="tcpwrapped" method="probed" conf="8"/></port>
+</ports>
+</host>
+<host><status state="up" reason="syn-ack"/>
+<address addr="8.8.4.4" addrtype="ipv4"/>
+<ports>
+<port protocol="tcp" portid="443"><state state="open" reason="syn-ack"/><service name="https" method="table" conf="3"/></port>
+<port protocol="tcp" portid="22"><state state="open" reason="syn-ack"/><service name="ssh" method="table" conf="3"/></port>
+</ports>
+</host>
+<host><status state="down" reason="no-response"/>
+<address addr="10.0.0.9" addrtype="ipv4"/>
+</host>
+</nmaprun>`
+
+func newTestView(t *testing.T) *View {
+	t.Helper()
+
+	run, err := nmap.Parse([]byte(viewTestXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewNmapView(run)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func jsonOpenPorts(t *testing.T, v *View, options ViewOptions) ([]string, []string) {
+	var buf bytes.Buffer
+	v.SetOutput(&buf)
+	err := v.PrintJSON(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hosts []nmap.Host
+	err = json.Unmarshal(buf.Bytes(), &hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ips := map[string]bool{}
+	ports := map[string]bool{}
+	for _, h := range hosts {
+		for _, addr := range h.Addresses {
+			ips[addr.Addr] = true
+			for _, p := range h.Ports {
+				if portIsOpen(&p) {
+					ports[fmt.Sprintf("%s:%d", addr.Addr, p.ID)] = true
+				}
+			}
+		}
+	}
+	return sortedKeys(ips), sortedKeys(ports)
+}
+
+func tableOpenPorts(v *View, options ViewOptions) ([]string, []string) {
+	ips := map[string]bool{}
+	ports := map[string]bool{}
+	_, rows := v.tableRows("IP", options)
+	for _, row := range rows {
+		for _, ip := range strings.Split(row[0], "\n") {
+			ips[ip] = true
+			for _, port := range strings.FieldsFunc(row[2], func(r rune) bool { return r == ',' || r == '\n' }) {
+				ports[fmt.Sprintf("%s:%s", ip, port)] = true
+			}
+		}
+	}
+	return sortedKeys(ips), sortedKeys(ports)
+}
+
+func portIPs(ports []string) []string {
+	ips := map[string]bool{}
+	for _, port := range ports {
+		ip, _, _ := strings.Cut(port, ":")
+		ips[ip] = true
+	}
+	return sortedKeys(ips)
+}
+
+func listIPs(v *View, options ViewOptions) []string {
+	var buf bytes.Buffer
+	v.SetOutput(&buf)
+	v.PrintList(options | ListIPs)
+	return strings.Fields(buf.String())
+}
+
+func urlPorts(t *testing.T, v *View, options ViewOptions) []string {
+	ports := map[string]bool{}
+	for _, u := range v.GetURLs("", options) {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if net.ParseIP(parsed.Hostname()) == nil {
+			continue
+		}
+
+		port := parsed.Port()
+		if port == "" && parsed.Scheme == "http" {
+			port = "80"
+		} else if port == "" && parsed.Scheme == "https" {
+			port = "443"
+		}
+		ports[fmt.Sprintf("%s:%s", parsed.Hostname(), port)] = true
+	}
+	return sortedKeys(ports)
+}
+
+func TestViewOutputsAgree(t *testing.T) {
+	tests := []struct {
+		name         string
+		options      ViewOptions
+		excludePorts []int
+		includePorts []int
+		wantPorts    []string
+	}{
+		{
+			name:      "no filters",
+			wantPorts: []string{"10.0.0.1:22", "10.0.0.1:445", "10.0.0.1:80", "8.8.4.4:22", "8.8.4.4:443", "8.8.8.8:8443"},
+		},
+		{
+			name:      "no tcpwrapped",
+			options:   IgnoreTCPWrapped,
+			wantPorts: []string{"10.0.0.1:22", "10.0.0.1:80", "8.8.4.4:22", "8.8.4.4:443"},
+		},
+		{
+			name:         "excluded ports",
+			options:      ViewOpenPorts,
+			excludePorts: []int{22, 8443},
+			wantPorts:    []string{"10.0.0.1:445", "10.0.0.1:80", "8.8.4.4:443"},
+		},
+		{
+			name:         "included ports",
+			options:      ViewPublic,
+			includePorts: []int{443},
+			wantPorts:    []string{"8.8.4.4:443"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newTestView(t)
+			v.SetExcludePorts(tt.excludePorts)
+			v.SetIncludePorts(tt.includePorts)
+
+			jsonIPs, jsonPorts := jsonOpenPorts(t, v, tt.options)
+			if !slices.Equal(jsonPorts, tt.wantPorts) {
+				t.Errorf("PrintJSON() ports = %v, want %v", jsonPorts, tt.wantPorts)
+			}
+
+			tableIPs, tablePorts := tableOpenPorts(v, tt.options)
+			if !slices.Equal(tablePorts, jsonPorts) {
+				t.Errorf("PrintTable() ports = %v, PrintJSON() ports = %v", tablePorts, jsonPorts)
+			}
+
+			// the table leaves out hosts without open ports when tcpwrapped
+			// ports are hidden
+			wantTableIPs := jsonIPs
+			if tt.options&IgnoreTCPWrapped != 0 {
+				wantTableIPs = portIPs(jsonPorts)
+			}
+			if !slices.Equal(tableIPs, wantTableIPs) {
+				t.Errorf("PrintTable() IPs = %v, want %v", tableIPs, wantTableIPs)
+			}
+
+			if got := listIPs(v, tt.options); !slices.Equal(got, jsonIPs) {
+				t.Errorf("PrintList() IPs = %v, PrintJSON() IPs = %v", got, jsonIPs)
+			}
+
+			// URLs are never built for tcpwrapped ports
+			_, wantURLPorts := jsonOpenPorts(t, v, tt.options|IgnoreTCPWrapped)
+			if got := urlPorts(t, v, tt.options); !slices.Equal(got, wantURLPorts) {
+				t.Errorf("GetURLs() ports = %v, want %v", got, wantURLPorts)
+			}
+		})
+	}
+}
+
+func TestViewJSONScripts(t *testing.T) {
+	v := newTestView(t)
+	port := &v.run.Hosts[0].Ports[0]
+	port.Scripts = []nmap.Script{
+		{ID: "ssl-cert", Output: "Subject: commonName=web.example.com\nPublic Key bits: many"},
+		{ID: "banner", Output: "SSH-2.0-OpenSSH_8.9"},
+	}
+	setPortLastConfirmed(port, time.Unix(1000, 0))
+
+	var buf bytes.Buffer
+	v.SetOutput(&buf)
+	err := v.PrintJSON(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hosts []struct {
+		Ports []struct {
+			ID      uint16                       `json:"id"`
+			Scripts []map[string]json.RawMessage `json:"scripts"`
+		} `json:"ports"`
+	}
+	err = json.Unmarshal(buf.Bytes(), &hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, script := range hosts[0].Ports[0].Scripts {
+		var id string
+		err = json.Unmarshal(script["id"], &id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := script["parsed"]; ok {
+			id += " parsed"
+		}
+		got = append(got, id)
+	}
+
+	if want := []string{"ssl-cert", "banner"}; !slices.Equal(got, want) {
+		t.Errorf("PrintJSON() scripts = %v, want %v", got, want)
+	}
+}
+
+func TestViewGetRun(t *testing.T) {
+	tests := []struct {
+		name         string
+		options      ViewOptions
+		excludePorts []int
+		wantPorts    []string
+	}{
+		{
+			name:      "no filters",
+			wantPorts: []string{"10.0.0.1:22", "10.0.0.1:25", "10.0.0.1:445", "10.0.0.1:80", "10.0.0.9", "8.8.4.4:22", "8.8.4.4:443", "8.8.8.8:8443"},
+		},
+		{
+			name:         "open ports without tcpwrapped",
+			options:      ViewOpenPorts | IgnoreTCPWrapped,
+			excludePorts: []int{22},
+			wantPorts:    []string{"10.0.0.1:80", "8.8.4.4:443"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newTestView(t)
+			v.SetExcludePorts(tt.excludePorts)
+			setPortLastConfirmed(&v.run.Hosts[0].Ports[0], time.Unix(1000, 0))
+
+			run, err := v.GetRun(tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join(t.TempDir(), "view.xml")
+			err = run.ToFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			written, err := nmap.Parse(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, h := range written.Hosts {
+				if len(h.Ports) == 0 {
+					got = append(got, h.Addresses[0].Addr)
+				}
+
+				for _, p := range h.Ports {
+					got = append(got, fmt.Sprintf("%s:%d", h.Addresses[0].Addr, p.ID))
+					for _, script := range p.Scripts {
+						if strings.HasPrefix(script.ID, nexScriptPrefix) {
+							t.Errorf("GetRun() kept pseudo script %s on %s:%d", script.ID, h.Addresses[0].Addr, p.ID)
+						}
+					}
+				}
+
+				if h.Addresses[0].Addr == "10.0.0.1" && (len(h.Hostnames) != 1 || h.Hostnames[0].Name != "web.example.com") {
+					t.Errorf("GetRun() hostnames = %v, want web.example.com", h.Hostnames)
+				}
+			}
+			slices.Sort(got)
+
+			if !slices.Equal(got, tt.wantPorts) {
+				t.Errorf("GetRun() ports = %v, want %v", got, tt.wantPorts)
+			}
+		})
+	}
+}
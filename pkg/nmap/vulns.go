@@ -0,0 +1,206 @@
+package nmap
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+// Sources of vulnerabilities.
+const (
+	VulnSourceVulners = "vulners"
+	VulnSourceVulscan = "vulscan"
+	VulnSourceNVD     = "nvd"
+)
+
+// Vuln sort orders.
+const (
+	VulnSortScore = "score"
+	VulnSortHost  = "host"
+	VulnSortID    = "id"
+)
+
+var (
+	cveRe     = regexp.MustCompile(`^CVE-\d{4}-\d+$`)
+	cveInIDRe = regexp.MustCompile(`CVE-\d{4}-\d+`)
+)
+
+// Vuln is a vulnerability reported for a port.
+type Vuln struct {
+	ID          string  `json:"id"`
+	CVSS        float64 `json:"cvss"`
+	IsExploit   bool    `json:"is_exploit"`
+	Host        string  `json:"host"`
+	Port        uint16  `json:"port"`
+	Protocol    string  `json:"protocol"`
+	Product     string  `json:"product"`
+	CPE         string  `json:"cpe,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Source      string  `json:"source"`
+}
+
+// Endpoint returns the affected host:port/product.
+func (v Vuln) Endpoint() string {
+	endpoint := fmt.Sprintf("%s:%d", v.Host, v.Port)
+	if v.Product != "" {
+		endpoint = fmt.Sprintf("%s/%s", endpoint, v.Product)
+	}
+	return endpoint
+}
+
+// VulnOptions filters and sorts the vulnerabilities returned by GetVulns.
+type VulnOptions struct {
+	MinCVSS float64
+	// AllIDs keeps exploit and advisory IDs that are not CVEs.
+	AllIDs bool
+	SortBy string
+}
+
+// GetVulns returns the vulnerabilities found by the vulners and vulscan
+// scripts on the open ports, along with the CVEs matched to their services
+// when a CVE index is set. A vulnerability reported by several sources is
+// only listed once.
+func (v *View) GetVulns(options ViewOptions, vulnOptions VulnOptions) []Vuln {
+	var vulns []Vuln
+	seen := map[string]int{}
+	exploited := map[string]bool{}
+	vulnKey := func(vuln Vuln, id string) string {
+		return fmt.Sprintf("%s|%d/%s|%s", vuln.Host, vuln.Port, vuln.Protocol, id)
+	}
+
+	add := func(vuln Vuln) {
+		// exploits named after a CVE, like PRION:CVE-2021-41773, flag it
+		if vuln.IsExploit && !cveRe.MatchString(vuln.ID) {
+			if cve := cveInIDRe.FindString(vuln.ID); cve != "" {
+				exploited[vulnKey(vuln, cve)] = true
+			}
+		}
+
+		if !vulnOptions.AllIDs && !cveRe.MatchString(vuln.ID) {
+			return
+		}
+
+		key := vulnKey(vuln, vuln.ID)
+		if i, ok := seen[key]; ok {
+			vuln.IsExploit = vuln.IsExploit || vulns[i].IsExploit
+			vulns[i].IsExploit = vuln.IsExploit
+
+			// prefer the entry with a score
+			if vulns[i].CVSS == 0 {
+				vuln.Description = cmp.Or(vuln.Description, vulns[i].Description)
+				vulns[i] = vuln
+			}
+			return
+		}
+
+		seen[key] = len(vulns)
+		vulns = append(vulns, vuln)
+	}
+
+	for _, h := range v.GetHostsWithOptions(options | ViewOpenPorts) {
+		host := h.Addresses[0].Addr
+		for _, port := range h.Ports {
+			base := Vuln{
+				Host:     host,
+				Port:     port.ID,
+				Protocol: newPortKey(port).protocol,
+				Product:  serviceProduct(port.Service),
+			}
+
+			if script, ok := findScript(port.Scripts, "vulscan"); ok {
+				for _, entry := range ParseVulscan(script) {
+					vuln := base
+					vuln.ID = entry.ID
+					vuln.Description = entry.Description
+					vuln.Source = VulnSourceVulscan
+					add(vuln)
+				}
+			}
+
+			if script, ok := findScript(port.Scripts, "vulners"); ok {
+				cpes, err := ParseVulners(script)
+				if err != nil {
+					log.Printf("[!] Skipping vulners entries for %s port %d/%s: %s", host, port.ID, port.Protocol, err)
+				}
+
+				for _, cpe := range cpes {
+					for _, entry := range cpe.Vulnerabilities {
+						vuln := base
+						vuln.ID = entry.ID
+						vuln.CVSS = entry.CVSS
+						vuln.IsExploit = entry.IsExploit
+						vuln.CPE = cpe.CPE
+						vuln.Source = VulnSourceVulners
+						add(vuln)
+					}
+				}
+			}
+
+			if v.cveIndex != nil {
+				for _, match := range v.cveIndex.Match(port.Service) {
+					vuln := base
+					vuln.ID = match.ID
+					vuln.CVSS = match.CVSS
+					vuln.CPE = match.CPE
+					vuln.Source = VulnSourceNVD
+					add(vuln)
+				}
+			}
+		}
+	}
+
+	var filtered []Vuln
+	for _, vuln := range vulns {
+		if exploited[vulnKey(vuln, vuln.ID)] {
+			vuln.IsExploit = true
+		}
+
+		if vuln.CVSS >= vulnOptions.MinCVSS {
+			filtered = append(filtered, vuln)
+		}
+	}
+
+	sortVulns(filtered, vulnOptions.SortBy)
+	return filtered
+}
+
+// sortVulns sorts by score (highest first), host or ID, breaking ties with
+// the other two.
+func sortVulns(vulns []Vuln, sortBy string) {
+	byScore := func(a, b Vuln) int {
+		return cmp.Compare(b.CVSS, a.CVSS)
+	}
+	byHost := func(a, b Vuln) int {
+		return cmp.Or(strings.Compare(a.Host, b.Host), cmp.Compare(a.Port, b.Port), strings.Compare(a.Protocol, b.Protocol))
+	}
+	byID := func(a, b Vuln) int {
+		return strings.Compare(a.ID, b.ID)
+	}
+
+	order := []func(a, b Vuln) int{byScore, byHost, byID}
+	switch sortBy {
+	case VulnSortHost:
+		order = []func(a, b Vuln) int{byHost, byScore, byID}
+	case VulnSortID:
+		order = []func(a, b Vuln) int{byID, byHost}
+	}
+
+	slices.SortStableFunc(vulns, func(a, b Vuln) int {
+		for _, compare := range order {
+			if c := compare(a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+}
+
+// serviceProduct returns the product and version of a service.
+func serviceProduct(service nmap.Service) string {
+	return strings.TrimSpace(fmt.Sprintf("%s %s", service.Product, service.Version))
+}
@@ -0,0 +1,74 @@
+package nmap
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+func TestGetVulns(t *testing.T) {
+	vulners := nmap.Script{ID: "vulners", Tables: []nmap.Table{{
+		Key: "cpe:/a:openbsd:openssh:7.4",
+		Tables: []nmap.Table{
+			{Elements: []nmap.Element{{Key: "id", Value: "CVE-2018-15473"}, {Key: "type", Value: "cve"}, {Key: "cvss", Value: "5.3"}}},
+			{Elements: []nmap.Element{{Key: "id", Value: "EDB-ID:45233"}, {Key: "type", Value: "exploitdb"}, {Key: "cvss", Value: "5.3"}, {Key: "is_exploit", Value: "true"}}},
+			{Elements: []nmap.Element{{Key: "id", Value: "CVE-2023-38408"}, {Key: "type", Value: "cve"}, {Key: "cvss", Value: "9.8"}, {Key: "is_exploit", Value: "true"}}},
+			{Elements: []nmap.Element{{Key: "id", Value: "CVE-2099-0001"}, {Key: "type", Value: "cve"}, {Key: "cvss", Value: "n/a"}}},
+			{Elements: []nmap.Element{{Key: "id", Value: "PRION:CVE-2016-10009"}, {Key: "type", Value: "prion"}, {Key: "cvss", Value: "7.3"}, {Key: "is_exploit", Value: "true"}}},
+		},
+	}}}
+	vulscan := nmap.Script{ID: "vulscan", Output: "MITRE CVE - https://cve.mitre.org:\n" +
+		"[CVE-2018-15473] OpenSSH through 7.7 is prone to a user enumeration vulnerability\n" +
+		"[CVE-2016-10009] Untrusted search path vulnerability in ssh-agent.c\n"}
+
+	run := &nmap.Run{Hosts: []nmap.Host{{
+		Status:    nmap.Status{State: "up"},
+		Addresses: []nmap.Address{{Addr: "10.0.0.1", AddrType: "ipv4"}},
+		Ports: []nmap.Port{{
+			ID:       22,
+			Protocol: "tcp",
+			State:    nmap.State{State: "open"},
+			Service:  nmap.Service{Name: "ssh", Product: "OpenSSH", Version: "7.4"},
+			Scripts:  []nmap.Script{vulners, vulscan},
+		}},
+	}}}
+
+	tests := []struct {
+		name    string
+		options VulnOptions
+		want    []string
+	}{
+		{
+			name: "sorted by score",
+			want: []string{
+				"CVE-2023-38408 9.8 true vulners 10.0.0.1:22/OpenSSH 7.4",
+				"CVE-2018-15473 5.3 false vulners 10.0.0.1:22/OpenSSH 7.4",
+				"CVE-2016-10009 0.0 true vulscan 10.0.0.1:22/OpenSSH 7.4",
+			},
+		},
+		{
+			name:    "minimum CVSS with all IDs",
+			options: VulnOptions{MinCVSS: 5, AllIDs: true, SortBy: VulnSortID},
+			want: []string{
+				"CVE-2018-15473 5.3 false vulners 10.0.0.1:22/OpenSSH 7.4",
+				"CVE-2023-38408 9.8 true vulners 10.0.0.1:22/OpenSSH 7.4",
+				"EDB-ID:45233 5.3 true vulners 10.0.0.1:22/OpenSSH 7.4",
+				"PRION:CVE-2016-10009 7.3 true vulners 10.0.0.1:22/OpenSSH 7.4",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, vuln := range NewNmapView(run).GetVulns(0, tt.options) {
+				got = append(got, fmt.Sprintf("%s %.1f %t %s %s", vuln.ID, vuln.CVSS, vuln.IsExploit, vuln.Source, vuln.Endpoint()))
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GetVulns() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package nmap
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+// Operators of where conditions.
+const (
+	WhereEquals     = "=="
+	WhereNotEquals  = "!="
+	WhereMatches    = "=~"
+	WhereNotMatches = "!~"
+)
+
+var whereOperators = []string{WhereEquals, WhereNotEquals, WhereMatches, WhereNotMatches}
+
+// WhereCondition compares a host attribute, like the owner from an asset
+// inventory, to a value.
+type WhereCondition struct {
+	Key      string
+	Operator string
+	Value    string
+
+	re *regexp.Regexp
+}
+
+// ParseWhereCondition parses a condition like "environment == prod",
+// `owner != ""` or "business_unit =~ ^fin". Equality is case insensitive
+// and missing attributes are empty.
+func ParseWhereCondition(condition string) (WhereCondition, error) {
+	index := -1
+	operator := ""
+	for _, op := range whereOperators {
+		i := strings.Index(condition, op)
+		if i != -1 && (index == -1 || i < index) {
+			index = i
+			operator = op
+		}
+	}
+
+	if index == -1 {
+		return WhereCondition{}, fmt.Errorf("invalid condition %q, expected key, one of %s and a value", condition, strings.Join(whereOperators, " "))
+	}
+
+	where := WhereCondition{
+		Key:      strings.ToLower(strings.TrimSpace(condition[:index])),
+		Operator: operator,
+		Value:    strings.TrimSpace(condition[index+len(operator):]),
+	}
+
+	if where.Key == "" {
+		return WhereCondition{}, fmt.Errorf("invalid condition %q, missing key", condition)
+	}
+
+	if len(where.Value) >= 2 && (where.Value[0] == '"' || where.Value[0] == '\'') && where.Value[len(where.Value)-1] == where.Value[0] {
+		where.Value = where.Value[1 : len(where.Value)-1]
+	}
+
+	if operator == WhereMatches || operator == WhereNotMatches {
+		var err error
+		where.re, err = regexp.Compile(where.Value)
+		if err != nil {
+			return WhereCondition{}, fmt.Errorf("invalid condition %q: %w", condition, err)
+		}
+	}
+	return where, nil
+}
+
+func (w WhereCondition) matches(attributes map[string]string) bool {
+	value := attributes[w.Key]
+	switch w.Operator {
+	case WhereEquals:
+		return strings.EqualFold(value, w.Value)
+	case WhereNotEquals:
+		return !strings.EqualFold(value, w.Value)
+	case WhereMatches:
+		return w.re.MatchString(value)
+	default:
+		return !w.re.MatchString(value)
+	}
+}
+
+// SetWhere only keeps hosts whose attributes match every condition. The
+// keys of the conditions have to be attributes of hosts, so the asset
+// inventory and GeoIP databases have to be set first.
+func (v *View) SetWhere(conditions []WhereCondition) error {
+	keys := v.attributeKeys()
+	for _, condition := range conditions {
+		if slices.Contains(keys, condition.Key) {
+			continue
+		}
+
+		if len(keys) == 0 {
+			return fmt.Errorf("unknown attribute %q in where condition, hosts have no attributes without an asset inventory or GeoIP databases", condition.Key)
+		}
+		return fmt.Errorf("unknown attribute %q in where condition, expected one of: %s", condition.Key, strings.Join(keys, ", "))
+	}
+
+	v.where = conditions
+	return nil
+}
+
+// HostAttributes returns the attributes of the host used by the where
+// filter and shown as columns, like the owner from an asset inventory or the
+// ASN from GeoIP databases.
+func (v *View) HostAttributes(h *nmap.Host) map[string]string {
+	attributes := map[string]string{}
+	if v.geoIP != nil {
+		if info, ok := v.geoIP.hostGeo(h); ok {
+			maps.Copy(attributes, info.attributes())
+		}
+	}
+
+	// the asset inventory knows better than GeoIP databases
+	if v.assets != nil {
+		maps.Copy(attributes, v.assets.attributes(h))
+	}
+	return attributes
+}
+
+// attributeKeys returns the keys of the attributes of hosts.
+func (v *View) attributeKeys() []string {
+	var keys []string
+	if v.assets != nil {
+		keys = append(keys, v.assets.Keys...)
+	}
+
+	if v.geoIP != nil {
+		keys = append(keys, geoKeys...)
+	}
+	return keys
+}
+
+// matchesWhere reports whether the host matches the where conditions.
+func (v *View) matchesWhere(h *nmap.Host) bool {
+	if len(v.where) == 0 {
+		return true
+	}
+
+	attributes := v.HostAttributes(h)
+	for _, condition := range v.where {
+		if !condition.matches(attributes) {
+			return false
+		}
+	}
+	return true
+}
@@ -3,9 +3,8 @@ package nmap
 import (
 	"encoding/xml"
 	"fmt"
-	"log"
 	"os"
-	"sort"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -88,7 +87,18 @@ func newXMLRun(run *nmap.Run) *nmap.Run {
 	}
 }
 
+// XMLMerge reads the nmap XML files and merges them into one run.
 func XMLMerge(paths []string, opts ...Option) (*nmap.Run, error) {
+	scans, err := ReadScans(paths)
+	if err != nil {
+		return nil, err
+	}
+	return MergeScans(scans, opts...)
+}
+
+// MergeScans merges scans read by ReadScans into one run, so callers that
+// also need the individual scans only parse each file once.
+func MergeScans(scans []*Scan, opts ...Option) (*nmap.Run, error) {
 	options := &Options{}
 	for _, o := range opts {
 		o(options)
@@ -96,28 +106,27 @@ func XMLMerge(paths []string, opts ...Option) (*nmap.Run, error) {
 
 	var merged *nmap.Run
 	hostsMap := make(map[string]nmap.Host)
-	for _, path := range paths {
-		data, err := os.ReadFile(path)
-		if err != nil {
-			return nil, err
-		}
+	scansMap := make(map[string][]scanRecord)
+	for _, scan := range scans {
+		run := scan.Run
+		for _, h := range run.Hosts {
+			// stamping the ports must not change the scan itself
+			h.Ports = slices.Clone(h.Ports)
 
-		run, err := nmap.Parse(data)
-		if err != nil {
-			log.Printf("[!] Skipping %s due to error: %s", path, err)
-			continue
-			// return nil, err
-		}
+			seen := hostSeenAt(run, h)
+			previouslyMerged := stampPorts(&h, seen)
 
-		for _, h := range run.Hosts {
-			var foundHost nmap.Host
-			var foundHostKey string
-			ok := false
+			record := scanRecord{seen: seen}
+			if h.Status.State == "up" && !previouslyMerged {
+				// Merged files only keep the first run's scaninfo, so they
+				// can't be trusted to say which ports were probed.
+				record.coverage = scan.Coverage
+			}
 
+			foundHostKey := ""
 			for _, ipAddr := range h.Addresses {
-				foundHostKey = ipAddr.String()
-				foundHost, ok = hostsMap[foundHostKey]
-				if ok {
+				if _, ok := hostsMap[ipAddr.String()]; ok {
+					foundHostKey = ipAddr.String()
 					break
 				}
 			}
@@ -137,12 +146,14 @@ func XMLMerge(paths []string, opts ...Option) (*nmap.Run, error) {
 			//	}
 			//}
 
-			if len(foundHost.Addresses) > 0 {
-				hostsMap[foundHostKey] = mergeHost(foundHost, h)
+			if foundHostKey != "" {
+				hostsMap[foundHostKey] = mergeHost(hostsMap[foundHostKey], h)
 			} else {
 				// no host found. add new host based on first IP
-				hostsMap[h.Addresses[0].String()] = h
+				foundHostKey = h.Addresses[0].String()
+				hostsMap[foundHostKey] = h
 			}
+			scansMap[foundHostKey] = append(scansMap[foundHostKey], record)
 		}
 
 		if merged == nil {
@@ -162,31 +173,27 @@ func XMLMerge(paths []string, opts ...Option) (*nmap.Run, error) {
 		return nil, fmt.Errorf("no nmap files merged")
 	}
 
-	for _, h := range hostsMap {
-		if options.openOnly {
-			var ports []nmap.Port
-			for _, p := range h.Ports {
-				if strings.Contains(p.State.State, "open") {
-					ports = append(ports, p)
-				}
-			}
-
-			if len(ports) == 0 {
-				continue
-			}
+	for key, h := range hostsMap {
+		h.Ports = dropSupersededPorts(h.Ports, scansMap[key])
 
-			h.Ports = ports
+		h, ok := options.filterHost(h)
+		if !ok {
+			continue
 		}
 
-		sort.Slice(h.Ports, func(i, j int) bool {
-			return h.Ports[i].ID < h.Ports[j].ID
-		})
+		sortPorts(h.Ports)
 
 		merged.Hosts = append(merged.Hosts, h)
 
 	}
 
-	bytes, err := xml.MarshalIndent(merged, "", "  ")
+	return rebuildRun(merged)
+}
+
+// rebuildRun marshals the run and parses it back, so the returned run holds
+// the raw XML used by ToFile and ToReader.
+func rebuildRun(run *nmap.Run) (*nmap.Run, error) {
+	bytes, err := xml.MarshalIndent(run, "", "  ")
 	if err != nil {
 		return nil, err
 	}
@@ -241,38 +248,23 @@ func mergeHost(h1 nmap.Host, h2 nmap.Host) nmap.Host {
 		merged.EndTime = h2.EndTime
 	}
 
-	tcpPortMap := make(map[uint16]nmap.Port)
-	udpPortMap := make(map[uint16]nmap.Port)
+	portMap := make(map[portKey]nmap.Port)
 	for _, port := range h1.Ports {
-		if strings.EqualFold(port.Protocol, "tcp") {
-			tcpPortMap[port.ID] = port
-		} else {
-			udpPortMap[port.ID] = port
-		}
+		portMap[newPortKey(port)] = port
 	}
 
 	for _, port := range h2.Ports {
-		var portMap map[uint16]nmap.Port
-		if strings.EqualFold(port.Protocol, "tcp") {
-			portMap = tcpPortMap
-		} else {
-			portMap = udpPortMap
-		}
-
-		foundPort, ok := portMap[port.ID]
-		if !ok || start2 > start1 { // If not found or if h2 started after h1
-			portMap[port.ID] = port
+		key := newPortKey(port)
+		foundPort, ok := portMap[key]
+		if !ok {
+			portMap[key] = port
 			continue
 		}
 
-		portMap[port.ID] = mergePort(foundPort, port)
+		portMap[key] = mergeObservedPort(foundPort, port)
 	}
 
-	for _, p := range tcpPortMap {
-		merged.Ports = append(merged.Ports, p)
-	}
-
-	for _, p := range udpPortMap {
+	for _, p := range portMap {
 		merged.Ports = append(merged.Ports, p)
 	}
 
@@ -317,6 +309,49 @@ func mergePort(p1 nmap.Port, p2 nmap.Port) nmap.Port {
 		Owner:    p1.Owner,
 		Service:  svc,
 		State:    p1.State,
-		Scripts:  append(p1.Scripts, p2.Scripts...),
+		Scripts:  mergeScripts(p1.Scripts, p2.Scripts),
+	}
+}
+
+// mergeObservedPort merges two observations of the same port. The most
+// recently confirmed one decides the state, while service details still come
+// from whichever scan detected them best.
+func mergeObservedPort(p1 nmap.Port, p2 nmap.Port) nmap.Port {
+	confirmed1 := PortLastConfirmed(p1)
+	confirmed2 := PortLastConfirmed(p2)
+	if confirmed1.Equal(confirmed2) {
+		return mergePort(p1, p2)
+	}
+
+	newer, older := p2, p1
+	if confirmed1.After(confirmed2) {
+		newer, older = p1, p2
+	}
+
+	if newer.State.State == older.State.State {
+		newer.Service = mostAccurateService(newer.Service, older.Service)
+		newer.Scripts = mergeScripts(newer.Scripts, older.Scripts)
+	}
+	return newer
+}
+
+// mergeScripts appends the scripts from s2 that are not already in s1.
+// Scripts with the same ID but different output, like http-title run
+// against several virtual hosts, are all kept. The pseudo scripts nex adds
+// are only kept from s1.
+func mergeScripts(s1 []nmap.Script, s2 []nmap.Script) []nmap.Script {
+	scripts := append([]nmap.Script{}, s1...)
+	for _, script := range s2 {
+		duplicate := slices.ContainsFunc(scripts, func(s nmap.Script) bool {
+			if strings.HasPrefix(script.ID, nexScriptPrefix) {
+				return s.ID == script.ID
+			}
+			return s.ID == script.ID && s.Output == script.Output
+		})
+
+		if !duplicate {
+			scripts = append(scripts, script)
+		}
 	}
+	return scripts
 }
@@ -0,0 +1,255 @@
+package nmap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Ullaakut/nmap/v2"
+)
+
+func hostXML(addr string, state string, start int64, ports string) string {
+	return fmt.Sprintf(`<host starttime="%d" endtime="%d"><status state="%s" reason="syn-ack"/>
+<address addr="%s" addrtype="ipv4"/>
+<ports>%s</ports>
+</host>`, start, start+10, state, addr, ports)
+}
+
+func writeScan(t *testing.T, name string, start int64, services string, ports string) string {
+	return writeRun(t, name, start, services, hostXML("10.0.0.1", "up", start, ports))
+}
+
+func writeRun(t *testing.T, name string, start int64, services string, hosts string) string {
+	t.Helper()
+
+	data := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<nmaprun scanner="nmap" args="nmap" start="%d" version="7.94" xmloutputversion="1.05">
+<scaninfo type="syn" protocol="tcp" numservices="1" services="%s"/>
+%s
+</nmaprun>`, start, services, hosts)
+
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(data), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func openPort(id int) string {
+	return fmt.Sprintf(`<port protocol="tcp" portid="%d"><state state="open" reason="syn-ack"/><service name="unknown" method="table" conf="3"/></port>`, id)
+}
+
+func namedPort(id int, name string) string {
+	return fmt.Sprintf(`<port protocol="tcp" portid="%d"><state state="open" reason="syn-ack"/><service name="%s" method="probed" conf="10"/></port>`, id, name)
+}
+
+func hostAddrs(run *nmap.Run) []string {
+	var addrs []string
+	for _, h := range run.Hosts {
+		addrs = append(addrs, h.Addresses[0].Addr)
+	}
+	slices.Sort(addrs)
+	return addrs
+}
+
+func portIDs(h nmap.Host) []int {
+	var ids []int
+	for _, p := range h.Ports {
+		ids = append(ids, int(p.ID))
+	}
+	return ids
+}
+
+func TestXMLMerge(t *testing.T) {
+	tests := []struct {
+		name          string
+		newerServices string
+		newerPorts    string
+		want          []int
+		wantConfirmed map[int]int64
+	}{
+		{
+			name:          "newer scan supersedes the ports it covered",
+			newerServices: "1-100",
+			newerPorts:    openPort(22),
+			want:          []int{22},
+			wantConfirmed: map[int]int64{22: 2000},
+		},
+		{
+			name:          "narrower newer scan keeps ports outside its range",
+			newerServices: "443",
+			newerPorts:    openPort(443),
+			want:          []int{22, 80, 443},
+			wantConfirmed: map[int]int64{22: 1000, 80: 1000, 443: 2000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			older := writeScan(t, "older.xml", 1000, "1-1000", openPort(22)+openPort(80))
+			newer := writeScan(t, "newer.xml", 2000, tt.newerServices, tt.newerPorts)
+
+			// order of the files should not matter
+			for _, paths := range [][]string{{older, newer}, {newer, older}} {
+				run, err := XMLMerge(paths)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(run.Hosts) != 1 {
+					t.Fatalf("XMLMerge() hosts = %d, want 1", len(run.Hosts))
+				}
+
+				got := portIDs(run.Hosts[0])
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("XMLMerge() ports = %v, want %v", got, tt.want)
+				}
+
+				for _, p := range run.Hosts[0].Ports {
+					want := time.Unix(tt.wantConfirmed[int(p.ID)], 0)
+					if got := PortLastConfirmed(p); !got.Equal(want) {
+						t.Errorf("PortLastConfirmed(%d) = %v, want %v", p.ID, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestXMLMergeOptions(t *testing.T) {
+	hosts := hostXML("10.0.0.1", "up", 1000, openPort(22)+openPort(80)) +
+		hostXML("10.0.0.2", "down", 1000, "") +
+		hostXML("8.8.8.8", "up", 1000, namedPort(443, "tcpwrapped")) +
+		hostXML("8.8.4.4", "up", 1000, openPort(22))
+
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{
+			name: "no options",
+			want: []string{"10.0.0.1", "10.0.0.2", "8.8.4.4", "8.8.8.8"},
+		},
+		{
+			name: "up only",
+			opts: []Option{WithUpOnly()},
+			want: []string{"10.0.0.1", "8.8.4.4", "8.8.8.8"},
+		},
+		{
+			name: "public without tcpwrapped",
+			opts: []Option{WithPublicOnly(), WithoutTCPWrapped()},
+			want: []string{"8.8.4.4"},
+		},
+		{
+			name: "private with port 80",
+			opts: []Option{WithPrivateOnly(), WithIncludePorts([]int{80})},
+			want: []string{"10.0.0.1"},
+		},
+		{
+			name: "excluded ports and hosts",
+			opts: []Option{WithExcludePorts([]int{22}), WithHosts(nil, []string{"8.8.8.8"})},
+			want: []string{"10.0.0.1", "10.0.0.2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeRun(t, "scan.xml", 1000, "1-1000", hosts)
+			run, err := XMLMerge([]string{path}, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := hostAddrs(run); !slices.Equal(got, tt.want) {
+				t.Errorf("XMLMerge() hosts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXMLMergeProtocols(t *testing.T) {
+	protoPort := func(protocol string, id int) string {
+		return fmt.Sprintf(`<port protocol="%s" portid="%d"><state state="open" reason="response"/></port>`, protocol, id)
+	}
+
+	first := writeScan(t, "tcp.xml", 1000, "1-1000", protoPort("tcp", 80)+protoPort("sctp", 80))
+	second := writeScan(t, "udp.xml", 2000, "", protoPort("udp", 80)+protoPort("ip", 6))
+
+	run, err := XMLMerge([]string{first, second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, p := range run.Hosts[0].Ports {
+		got = append(got, fmt.Sprintf("%d/%s", p.ID, p.Protocol))
+	}
+
+	want := []string{"6/ip", "80/tcp", "80/udp", "80/sctp"}
+	if !slices.Equal(got, want) {
+		t.Errorf("XMLMerge() ports = %v, want %v", got, want)
+	}
+}
+
+func TestXMLMergeWithoutTimestamps(t *testing.T) {
+	path := writeScan(t, "scan.xml", 1000, "1-1000", openPort(22))
+
+	tests := []struct {
+		name string
+		opts []Option
+		want time.Time
+	}{
+		{name: "stamped", want: time.Unix(1000, 0)},
+		{name: "without timestamps", opts: []Option{WithoutTimestamps()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, err := XMLMerge([]string{path}, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := PortLastConfirmed(run.Hosts[0].Ports[0]); !got.Equal(tt.want) {
+				t.Errorf("PortLastConfirmed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXMLMergeScripts(t *testing.T) {
+	titlePort := func(titles ...string) string {
+		var scripts string
+		for _, title := range titles {
+			scripts += fmt.Sprintf(`<script id="http-title" output="%s"/>`, title)
+		}
+		return fmt.Sprintf(`<port protocol="tcp" portid="80"><state state="open" reason="syn-ack"/><service name="http" method="probed" conf="10"/>%s</port>`, scripts)
+	}
+
+	older := writeScan(t, "older.xml", 1000, "1-1000", titlePort("Intranet", "Wiki"))
+	newer := writeScan(t, "newer.xml", 2000, "1-1000", titlePort("Webmail", "Intranet"))
+
+	for _, paths := range [][]string{{older, newer}, {newer, older}} {
+		run, err := XMLMerge(paths)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, script := range run.Hosts[0].Ports[0].Scripts {
+			got = append(got, script.ID+" "+script.Output)
+		}
+
+		want := []string{
+			"http-title Webmail",
+			"http-title Intranet",
+			lastConfirmedScriptID + " " + time.Unix(2000, 0).UTC().Format(time.RFC3339),
+			"http-title Wiki",
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("XMLMerge() scripts = %v, want %v", got, want)
+		}
+	}
+}